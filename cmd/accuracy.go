@@ -0,0 +1,91 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Accuracy of the moves as on Lichess, https://lichess.org/page/accuracy. The
+// engine's evaluation in centipawns turns into the chances of winning,
+//
+//	win% = 50 + 50 * (2 / (1 + exp(-0.00368208 * centipawns)) - 1)
+//
+// and a move's accuracy follows from the chances it gave away,
+//
+//	accuracy% = 103.1668 * exp(-0.04354 * (win% before - win% after)) - 3.1669
+//
+// both from the side of the player moving, within 0 to 100. A player's
+// accuracy is the plain average over their moves, Lichess also weighs them
+// by how sharp the position was.
+func winPercent(centipawns int) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(centipawns)))-1)
+}
+
+func moveAccuracy(before, after float64) float64 {
+	accuracy := 103.1668*math.Exp(-0.04354*(before-after)) - 3.1669
+	return math.Max(0, math.Min(100, accuracy))
+}
+
+// Accuracy of each side over the game, for the sides with evaluated moves.
+// The change between two evaluations is put down to the last move between
+// them. Against the engine that is the human's move, the engine's own move
+// being the one its evaluation stands for.
+func gameAccuracy(game *chess.Game, evals []evalPoint) map[chess.Color]float64 {
+	positions := game.Positions()
+	sums := map[chess.Color]float64{}
+	counts := map[chess.Color]int{}
+	for i := 1; i < len(evals); i++ {
+		before, after := evals[i-1], evals[i]
+		if after.ply <= before.ply || after.ply-before.ply > 2 || after.ply >= len(positions) {
+			continue // Not known or not consecutive.
+		}
+		mover := positions[after.ply-1].Turn()
+		side := 1 // Evaluations are from White's side.
+		if mover == chess.Black {
+			side = -1
+		}
+		sums[mover] += moveAccuracy(winPercent(side*before.score), winPercent(side*after.score))
+		counts[mover]++
+	}
+
+	accuracy := map[chess.Color]float64{}
+	for color, n := range counts {
+		accuracy[color] = sums[color] / float64(n)
+	}
+	return accuracy
+}
+
+// Print the accuracy of the sides, if the game was evaluated.
+func printAccuracy(game *chess.Game, evals []evalPoint) {
+	accuracy := gameAccuracy(game, evals)
+	line := ""
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		if a, ok := accuracy[color]; ok {
+			if line != "" {
+				line += ", "
+			}
+			line += fmt.Sprintf("%s %.0f%%", playerName(color), a)
+		}
+	}
+	if line != "" {
+		fmt.Println("Accuracy:", line)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+const gAnalysisLines = 3 // Engine lines `/lines` shows unless told.
+
+// Analysis mode, `/analyze`: the sandbox with stepping through the moves and
+// the engine's best lines. The live game waits untouched in gSandbox until
+// `/play`.
+type analysis struct {
+	ahead []*chess.Move // Moves stepped back over, the next one first.
+}
+
+var gAnalysis *analysis
+
+// Leave the live game for analysis on a copy of it.
+func startAnalysis() {
+	gSandbox, gGame = gGame, gGame.Clone()
+	gAnalysis = &analysis{}
+	fmt.Println("Analyzing: play moves for both sides, step with", gConsole.Bold(gConsole.Yellow("/back")), "and",
+		gConsole.Bold(gConsole.Yellow("/forward")).String()+", see the engine's best moves with",
+		gConsole.Bold(gConsole.Yellow("/lines")).String()+".", gConsole.Bold(gConsole.Yellow("/play")), "gets back to the game.")
+}
+
+// Take back the last move of the analysis, `/forward` plays it again.
+func (a *analysis) back() {
+	moves := gGame.Moves()
+	if len(moves) == 0 {
+		fmt.Println("This is the start of the game.")
+		return
+	}
+	a.ahead = append([]*chess.Move{moves[len(moves)-1]}, a.ahead...)
+	gGame = rewindGame(gGame, len(moves)-1)
+	drawBoard(gGame)
+}
+
+// Play again the move stepped back over.
+func (a *analysis) forward() {
+	if len(a.ahead) == 0 {
+		fmt.Println("No move to step forward to.")
+		return
+	}
+	if err := applyMove(gGame, a.ahead[0]); err != nil {
+		fmt.Println(err)
+		return
+	}
+	a.ahead = a.ahead[1:]
+	drawBoard(gGame)
+}
+
+// A move of the human's own: the moves ahead are kept only if it is the next
+// of them.
+func (a *analysis) played(move *chess.Move) {
+	if len(a.ahead) > 0 && a.ahead[0].String() == move.String() {
+		a.ahead = a.ahead[1:]
+		return
+	}
+	a.ahead = nil
+}
+
+// Print the engine's best lines in the position, `/lines [N]`.
+func printLines(engine *uciEngine, game *chess.Game, args []string) {
+	n := gAnalysisLines
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			fmt.Println("Ask for a number of lines, e.g.", gConsole.Bold(gConsole.Yellow("/lines 5")))
+			return
+		}
+	}
+	if game.Outcome() != chess.NoOutcome {
+		fmt.Println("The game is over here.")
+		return
+	}
+
+	results, err := bestLines(engine, game, n)
+	if err != nil {
+		fmt.Println("Unable to analyze,", err)
+		return
+	}
+	for i, line := range results.Lines {
+		if i == n { // More lines than asked for.
+			break
+		}
+		fmt.Printf("  %d. %-7s %s\n", i+1, formatScore(line), gConsole.Bold(gConsole.Yellow(strings.Join(pvSAN(game, line.PV), " "))))
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// A king only takes last, when nothing can take it back.
+const gKingExchangeValue = 100
+
+var (
+	gKnightJumps = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	gKingSteps   = [][2]int{{0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-1, 0}, {-1, 1}}
+)
+
+// Value of the piece in an exchange, in pawns.
+func exchangeValue(piece chess.Piece) int {
+	if piece.Type() == chess.King {
+		return gKingExchangeValue
+	}
+	return gPieceValues[piece.Type()]
+}
+
+// Is the king of the side to move in check?
+func inCheck(pos *chess.Position) bool {
+	return checkedKing(pos) != chess.NoSquare
+}
+
+// Square of the king in check, chess.NoSquare when the side to move is not.
+func checkedKing(pos *chess.Position) chess.Square {
+	squares := pos.Board().SquareMap()
+	for sq, piece := range squares {
+		if piece.Type() == chess.King && piece.Color() == pos.Turn() {
+			if len(attackersOf(squares, sq, pos.Turn().Other())) > 0 {
+				return sq
+			}
+			break
+		}
+	}
+	return chess.NoSquare
+}
+
+// Pieces of the color that attack the square, or defend their own piece on
+// it, cheapest first. Pins are not taken into account.
+func attackersOf(squares map[chess.Square]chess.Piece, sq chess.Square, color chess.Color) []chess.Square {
+	file, rank := int(sq.File()), int(sq.Rank())
+	var found []chess.Square
+	at := func(f, r int) (chess.Square, chess.Piece, bool) {
+		if f < 0 || f > 7 || r < 0 || r > 7 {
+			return chess.NoSquare, chess.NoPiece, false
+		}
+		from := chess.Square(r*8 + f)
+		return from, squares[from], true
+	}
+	add := func(f, r int, kinds ...chess.PieceType) {
+		from, piece, ok := at(f, r)
+		if !ok || piece == chess.NoPiece || piece.Color() != color {
+			return
+		}
+		for _, kind := range kinds {
+			if piece.Type() == kind {
+				found = append(found, from)
+			}
+		}
+	}
+
+	pawnRank := rank - 1 // White pawns take up the board.
+	if color == chess.Black {
+		pawnRank = rank + 1
+	}
+	add(file-1, pawnRank, chess.Pawn)
+	add(file+1, pawnRank, chess.Pawn)
+	for _, jump := range gKnightJumps {
+		add(file+jump[0], rank+jump[1], chess.Knight)
+	}
+	for _, step := range gKingSteps {
+		add(file+step[0], rank+step[1], chess.King)
+		slider := chess.Rook // Along the files and ranks.
+		if step[0] != 0 && step[1] != 0 {
+			slider = chess.Bishop
+		}
+		for f, r := file+step[0], rank+step[1]; ; f, r = f+step[0], r+step[1] {
+			_, piece, ok := at(f, r)
+			if !ok {
+				break
+			}
+			if piece != chess.NoPiece {
+				add(f, r, slider, chess.Queen)
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return exchangeValue(squares[found[i]]) < exchangeValue(squares[found[j]])
+	})
+	return found
+}
+
+// Pawns the color wins by taking on the square with its cheapest piece, and
+// going on taking back as long as it pays. 0 if it had better not start.
+func exchangeGain(squares map[chess.Square]chess.Piece, sq chess.Square, color chess.Color) int {
+	attackers := attackersOf(squares, sq, color)
+	if len(attackers) == 0 {
+		return 0
+	}
+	if gain := captureGain(squares, sq, attackers[0]); gain > 0 {
+		return gain
+	}
+	return 0
+}
+
+// Pawns won by the capture on the square, after the best replies.
+func captureGain(squares map[chess.Square]chess.Piece, sq, from chess.Square) int {
+	after := make(map[chess.Square]chess.Piece, len(squares))
+	for s, p := range squares {
+		after[s] = p
+	}
+	captured := after[sq]
+	after[sq] = after[from]
+	delete(after, from)
+	return exchangeValue(captured) - exchangeGain(after, sq, after[sq].Color().Other())
+}
+
+// Name of the piece on the square, "Nf3" or "e4" for a pawn.
+func pieceOn(squares map[chess.Square]chess.Piece, sq chess.Square) string {
+	if kind := squares[sq].Type(); kind != chess.Pawn {
+		return strings.ToUpper(kind.String()) + sq.String()
+	}
+	return sq.String()
+}
+
+// List the pieces of both colors bearing on the square for `/attackers`, and
+// how an exchange there would go.
+func printAttackers(board *chess.Board, sq chess.Square) {
+	squares := board.SquareMap()
+	target, occupied := squares[sq]
+
+	fmt.Println(gConsole.Bold("Attackers of "+sq.String()).String(), gConsole.Faint("(pins not considered)"))
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		var names []string
+		for _, from := range attackersOf(squares, sq, color) {
+			names = append(names, pieceOn(squares, from))
+		}
+		if len(names) == 0 {
+			names = append(names, "none")
+		}
+		role := ""
+		if occupied {
+			role = " (attacking)"
+			if target.Color() == color {
+				role = " (defending)"
+			}
+		}
+		fmt.Println(gConsole.Bold(gConsole.Yellow(color.Name())).String()+role+":", strings.Join(names, ", "))
+	}
+
+	if !occupied {
+		fmt.Println("The square is empty, there is nothing to take.")
+		return
+	}
+	side := target.Color().Other()
+	attackers := attackersOf(squares, sq, side)
+	if target.Type() == chess.King {
+		if len(attackers) > 0 {
+			fmt.Println(side.Name(), "gives check.")
+		}
+		return
+	}
+	if len(attackers) == 0 {
+		fmt.Println(side.Name(), "can not take the", pieceTypeName(target.Type()), "on", sq.String()+".")
+		return
+	}
+	first := pieceOn(squares, attackers[0])
+	switch gain := captureGain(squares, sq, attackers[0]); {
+	case gain > 0:
+		fmt.Println(side.Name(), "comes out", strconv.Itoa(gain), "ahead starting with", first+", taking is safe.")
+	case gain == 0:
+		fmt.Println("Taking with", first, "is an even trade.")
+	case gain <= -gKingExchangeValue/2:
+		fmt.Println("Only the king attacks, and it can not take a defended piece.")
+	default:
+		fmt.Println(side.Name(), "comes out", strconv.Itoa(-gain), "behind starting with", first+", taking is not safe.")
+	}
+}
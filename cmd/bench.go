@@ -0,0 +1,176 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+	"github.com/spf13/cobra"
+)
+
+var gBenchMoveTime time.Duration
+
+// Test position of an EPD suite, e.g.
+// 2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001";
+type epdPosition struct {
+	id   string
+	fen  string
+	best []string // SAN moves of the "bm" operation.
+}
+
+// benchCmd runs an EPD test suite through the engine.
+var benchCmd = &cobra.Command{
+	Use:   "bench FILE",
+	Short: "Run an EPD test suite and score the engine's best moves",
+	Long: `Run an EPD test suite. The engine searches every position to the --depth,
+--nodes or --movetime limit and scores if it finds one of the "bm" moves.`,
+	Example: `  pinata bench wac.epd --movetime 1s`,
+	Args:    cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		suite, err := readEPD(args[0])
+		if err != nil {
+			fmt.Println("Unable to read the test suite,", err)
+			os.Exit(1)
+		}
+
+		if gBenchMoveTime > 0 && !cmd.Flags().Changed("depth") { // Search by time alone.
+			gEngineDepth = 0
+		}
+
+		engine, _ := newEngine(gEngineBinary)
+		defer engine.Close()
+		engine.IsReady()
+		bench(engine, suite)
+	},
+}
+
+// Read the positions of an EPD file. Positions without a "bm" operation are skipped.
+func readEPD(filename string) ([]epdPosition, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var suite []epdPosition
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pos, err := parseEPD(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if len(pos.best) == 0 {
+			continue
+		}
+		if pos.id == "" {
+			pos.id = "#" + strconv.Itoa(n)
+		}
+		suite = append(suite, pos)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(suite) == 0 {
+		return nil, fmt.Errorf("no positions with a best move in %s", filename)
+	}
+	return suite, nil
+}
+
+// Parse an EPD line: the first four FEN fields followed by operations ending in ';'.
+func parseEPD(line string) (epdPosition, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return epdPosition{}, fmt.Errorf("not an EPD position %q", line)
+	}
+	pos := epdPosition{fen: strings.Join(fields[:4], " ") + " 0 1"} // EPD leaves out the move counters.
+	if _, err := chess.FEN(pos.fen); err != nil {
+		return epdPosition{}, err
+	}
+
+	for _, op := range strings.Split(strings.Join(fields[4:], " "), ";") {
+		operands := strings.Fields(op)
+		if len(operands) == 0 {
+			continue
+		}
+		switch operands[0] {
+		case "bm":
+			pos.best = operands[1:]
+		case "id":
+			pos.id = strings.Trim(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(op), "id")), `"`)
+		}
+	}
+	return pos, nil
+}
+
+// Search every position and print the results with the score.
+func bench(engine *uciEngine, suite []epdPosition) {
+	params := searchLimits()
+	if gBenchMoveTime > 0 {
+		params = strings.TrimSpace(params + " movetime " + strconv.FormatInt(gBenchMoveTime.Milliseconds(), 10))
+	}
+	if params == "" {
+		params = "depth 10"
+	}
+
+	solved := 0
+	for _, pos := range suite {
+		fen, _ := chess.FEN(pos.fen)
+		game := chess.NewGame(fen)
+		move, results, err := searchMove(engine, game, params)
+		if err != nil {
+			fmt.Println(pos.id, gConsole.Bold(gConsole.Red("failed")).String()+":", err)
+			continue
+		}
+
+		found := "-"
+		if move != nil {
+			found = moveSAN(game, move)
+		}
+		score := ""
+		if info, ok := results.Best(); ok {
+			score = formatScore(info)
+		}
+
+		result := gConsole.Bold(gConsole.Red("missed"))
+		for _, best := range pos.best {
+			if plainSAN(best) == plainSAN(found) {
+				result = gConsole.Bold(gConsole.Green("solved"))
+				solved++
+				break
+			}
+		}
+		fmt.Printf("%-12s bm %-10s found %-8s %6s  %s\n", pos.id, strings.Join(pos.best, " "), found, score, result)
+	}
+	fmt.Printf("Solved %d of %d positions (%.1f%%)\n", solved, len(suite), 100*float64(solved)/float64(len(suite)))
+}
+
+func init() {
+	benchCmd.Flags().DurationVar(&gBenchMoveTime, "movetime", 0, "search time per position (default is by depth)")
+	rootCmd.AddCommand(benchCmd)
+}
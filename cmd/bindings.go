@@ -0,0 +1,76 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Single-key shortcuts, typed on an empty line and entered. Remapped in the
+// config file with `bind.<key> = "<command>"`, an empty command unbinds the
+// key. No move is written with a single character, so they cannot clash.
+var gKeyBindings = map[string]string{
+	"c": "/coach",
+	"d": "/describe",
+	"f": "/fen",
+	"v": "/visual",
+	"?": "/bindings",
+}
+
+// Bind a key from the config file.
+func setBinding(key, command string) error {
+	if utf8.RuneCountInString(key) != 1 || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("bind.%s: a binding is a single key", key)
+	}
+	command = strings.TrimSpace(command)
+	if command == "" {
+		delete(gKeyBindings, key)
+		return nil
+	}
+	if !strings.HasPrefix(command, "/") && command != "resign" {
+		return fmt.Errorf("bind.%s: %q is not a command", key, command)
+	}
+	gKeyBindings[key] = command
+	return nil
+}
+
+// The command bound to the input, the input itself if it is not a key.
+func expandBinding(input string) string {
+	if command, ok := gKeyBindings[input]; ok {
+		return command
+	}
+	return input
+}
+
+// List the key bindings for `/bindings`.
+func printBindings() {
+	if len(gKeyBindings) == 0 {
+		fmt.Println("No keys are bound.")
+		return
+	}
+	keys := make([]string, 0, len(gKeyBindings))
+	for key := range gKeyBindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Println(gConsole.Bold(gConsole.Yellow(key)), " ", gKeyBindings[key])
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/chzyer/readline"
+)
+
+// Blindfold test of `--blindfold-test`, after the moves played blind the
+// human sets up the position from memory.
+type blindfoldTest struct {
+	moves int
+	game  *chess.Game // The moves are counted in this game,
+	start int         // from this ply on.
+	done  bool
+}
+
+var gBlindfold *blindfoldTest
+
+// White's and Black's piece of the letter.
+var gPieceLetters = map[byte][2]chess.Piece{
+	'K': {chess.WhiteKing, chess.BlackKing}, 'Q': {chess.WhiteQueen, chess.BlackQueen},
+	'R': {chess.WhiteRook, chess.BlackRook}, 'B': {chess.WhiteBishop, chess.BlackBishop},
+	'N': {chess.WhiteKnight, chess.BlackKnight}, 'P': {chess.WhitePawn, chess.BlackPawn},
+}
+
+// Is it time for the test? The count starts over with a new game.
+func (t *blindfoldTest) due(game *chess.Game) bool {
+	if t == nil || t.done || gSandbox != nil || game.Outcome() != chess.NoOutcome {
+		return false
+	}
+	if t.game != game {
+		t.game, t.start = game, len(game.Moves())
+	}
+	return len(game.Moves())-t.start >= 2*t.moves && game.Position().Turn() == humanColor()
+}
+
+// Called off when the human looks at the board.
+func (t *blindfoldTest) peek() {
+	if t != nil && !t.done {
+		t.done = true
+		fmt.Println("You peeked, the blindfold test is off.")
+	}
+}
+
+// Ask for the position, score the recall and show the board.
+func (t *blindfoldTest) run(l *readline.Instance, game *chess.Game) {
+	t.done = true
+	fmt.Println(gConsole.Bold("Blindfold test:").String(), "where are the pieces? List them by square, e.g.",
+		gConsole.Bold(gConsole.Yellow("Ke1 Qd1 e4")).String()+", pawns without a letter.")
+
+	recalled := map[chess.Square]chess.Piece{}
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		for {
+			l.SetPrompt(color.Name() + " pieces: ")
+			input, err := l.Readline()
+			if err == readline.ErrInterrupt || err == io.EOF {
+				fmt.Println("Blindfold test abandoned.")
+				return
+			}
+			if err = parsePlacement(input, color, recalled); err == nil {
+				break
+			}
+			fmt.Println(err)
+		}
+	}
+
+	actual := game.Position().Board().SquareMap()
+	var wrong []chess.Square
+	squares := 0
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		p, ok := actual[sq]
+		r, recall := recalled[sq]
+		if !ok && !recall {
+			continue
+		}
+		squares++
+		if ok != recall || p != r {
+			wrong = append(wrong, sq)
+		}
+	}
+	score := blindfoldScore{Moves: t.moves, Correct: squares - len(wrong), Squares: squares}
+	fmt.Printf("You recalled %d of %d squares (%.0f%%).\n", score.Correct, score.Squares, score.percent())
+	sort.Slice(wrong, func(i, j int) bool { return wrong[i].String() < wrong[j].String() })
+	for _, sq := range wrong {
+		fmt.Println(" ", gConsole.Bold(gConsole.Red(sq.String())).String()+":", squareName(actual, sq)+", you had", squareName(recalled, sq))
+	}
+	recordBlindfold(score)
+
+	visual := gVisual
+	gVisual = true // The answer.
+	drawBoard(game)
+	gVisual = visual
+}
+
+// Place the pieces listed like "Ke1 Qd1 e4" for the color.
+func parsePlacement(input string, color chess.Color, squares map[chess.Square]chess.Piece) error {
+	side := 0
+	if color == chess.Black {
+		side = 1
+	}
+	placed := map[chess.Square]chess.Piece{}
+	for _, f := range strings.Fields(input) {
+		pieces, name := gPieceLetters['P'], f
+		if p, ok := gPieceLetters[f[0]]; ok {
+			pieces, name = p, f[1:]
+		}
+		sq, err := parseSquare(name)
+		if err != nil {
+			return fmt.Errorf("%q is not a piece on a square, write them like %s", f, gConsole.Bold(gConsole.Yellow("Nf3")))
+		}
+		if _, taken := squares[sq]; taken {
+			return fmt.Errorf("%s already has a piece", sq)
+		}
+		if _, twice := placed[sq]; twice {
+			return fmt.Errorf("%s is listed twice", sq)
+		}
+		placed[sq] = pieces[side]
+	}
+	for sq, p := range placed {
+		squares[sq] = p
+	}
+	return nil
+}
+
+// What stands on the square, "a white knight" or "nothing".
+func squareName(squares map[chess.Square]chess.Piece, sq chess.Square) string {
+	p, ok := squares[sq]
+	if !ok {
+		return "nothing"
+	}
+	return "a " + strings.ToLower(p.Color().Name()) + " " + pieceTypeName(p.Type())
+}
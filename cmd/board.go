@@ -0,0 +1,343 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Arrow drawn on the board for teaching or planning.
+type arrow struct {
+	from, to chess.Square
+}
+
+// Colors of the `--palette=cb` board for color vision deficiencies, orange and
+// sky blue from the Okabe-Ito palette. They stay apart with protanopia and
+// deuteranopia (red-green) as well as tritanopia (blue-yellow), where the default
+// brown highlight and yellow markers blend in. Symbols back the colors up: a star
+// on the highlighted squares and a dot on the empty dark squares.
+const (
+	gPaletteCBHighlight = 214 // Orange, 256-color index.
+	gPaletteCBMark      = 39  // Sky blue.
+	gPaletteCBCheck     = 166 // Vermillion, the king in check.
+)
+
+// Arrow directions clockwise from the top of the board.
+var (
+	gArrowHeads      = []string{"↑", "↗", "→", "↘", "↓", "↙", "←", "↖"}
+	gArrowHeadsASCII = []string{"^", "/", ">", "\\", "v", "/", "<", "\\"}
+)
+
+// Parse a square name like "e4".
+func parseSquare(name string) (chess.Square, error) {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return chess.NoSquare, fmt.Errorf("not a square %q", name)
+	}
+	return chess.Square(int(name[1]-'1')*8 + int(name[0]-'a')), nil
+}
+
+// Parse an arrow in coordinate notation like "e2e4".
+func parseArrow(str string) (arrow, error) {
+	str = strings.ToLower(str)
+	if len(str) != 4 {
+		return arrow{}, fmt.Errorf("not an arrow %q", str)
+	}
+	from, err := parseSquare(str[:2])
+	if err != nil {
+		return arrow{}, err
+	}
+	to, err := parseSquare(str[2:])
+	if err != nil {
+		return arrow{}, err
+	}
+	if from == to {
+		return arrow{}, fmt.Errorf("not an arrow %q", str)
+	}
+	return arrow{from: from, to: to}, nil
+}
+
+func (a arrow) String() string {
+	return a.from.String() + a.to.String()
+}
+
+// Direction marker of the arrow as seen from the side facing the board.
+func (a arrow) head(blackSide bool) string {
+	df := int(a.to.File()) - int(a.from.File())
+	dr := int(a.to.Rank()) - int(a.from.Rank())
+	if blackSide {
+		df, dr = -df, -dr
+	}
+
+	// Nearest of the eight directions, knight jumps lean towards the longer leg.
+	dir := 0
+	switch {
+	case df == 0 && dr > 0:
+		dir = 0
+	case df > 0 && dr > 0 && 2*dr > df && 2*df > dr:
+		dir = 1
+	case df > 0 && dr < 0 && -2*dr > df && 2*df > -dr:
+		dir = 3
+	case df < 0 && dr < 0 && 2*dr < df && 2*df < dr:
+		dir = 5
+	case df < 0 && dr > 0 && 2*dr > -df && -2*df > dr:
+		dir = 7
+	case abs(df) > abs(dr) && df > 0:
+		dir = 2
+	case abs(df) > abs(dr):
+		dir = 6
+	case dr < 0:
+		dir = 4
+	}
+
+	if gNoColor {
+		return gArrowHeadsASCII[dir]
+	}
+	return gArrowHeads[dir]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Markers overlaid on the squares: the arrow head next to the piece on the
+// from-square and a target on the to-square.
+func arrowMarks(arrows []arrow, blackSide bool) map[chess.Square]string {
+	target := "•"
+	if gNoColor {
+		target = "o"
+	}
+
+	marks := map[chess.Square]string{}
+	for _, a := range arrows {
+		marks[a.from] += a.head(blackSide)
+		if !strings.Contains(marks[a.to], target) {
+			marks[a.to] += target
+		}
+	}
+	return marks
+}
+
+// Squares changed by the last move: both squares of the move, also the rook's
+// squares when castling and the captured pawn's square en passant. A promoted
+// piece already stands on the destination square.
+func lastMoveSquares(game *chess.Game) map[chess.Square]bool {
+	moves := game.Moves()
+	if len(moves) == 0 {
+		return nil
+	}
+	move := moves[len(moves)-1]
+	squares := map[chess.Square]bool{move.S1(): true, move.S2(): true}
+
+	rank := int(move.S1().Rank())
+	switch {
+	case move.HasTag(chess.KingSideCastle): // Rook h -> f
+		squares[chess.Square(rank*8+7)] = true
+		squares[chess.Square(rank*8+5)] = true
+	case move.HasTag(chess.QueenSideCastle): // Rook a -> d
+		squares[chess.Square(rank*8)] = true
+		squares[chess.Square(rank*8+3)] = true
+	case move.HasTag(chess.EnPassant): // The pawn taken stands beside the one taking.
+		squares[chess.Square(rank*8+int(move.S2().File()))] = true
+	}
+	return squares
+}
+
+// Highlight a square of the last move. Without colors a symbol marks it, the cb
+// palette shows both.
+func highlightCell(cell string) string {
+	switch {
+	case gNoColor && cell == "":
+		return "."
+	case gNoColor:
+		return cell + "*"
+	case gPalette == "cb":
+		return gConsole.BgIndex(gPaletteCBHighlight, cell+"*").String()
+	case cell == "":
+		return gConsole.BgBrown(" ").String()
+	default:
+		return gConsole.BgBrown(cell).String()
+	}
+}
+
+// Highlight the king in check, red or the cb palette's vermillion. A mated king
+// is magenta on the default board, the check or checkmate mark of
+// `--check-marks` tells them apart on the others.
+func checkCell(cell string, mate bool) string {
+	check, mateMark := checkMarks()
+	if mate {
+		check = mateMark
+	}
+	switch {
+	case gNoColor:
+		return cell + check
+	case gPalette == "cb":
+		return gConsole.BgIndex(gPaletteCBCheck, cell+check).String()
+	case mate:
+		return gConsole.BgMagenta(cell).String()
+	default:
+		return gConsole.BgRed(cell).String()
+	}
+}
+
+// Color of the arrow markers.
+func markColor(mark string) string {
+	if gPalette == "cb" {
+		return gConsole.Bold(gConsole.Index(gPaletteCBMark, mark)).String()
+	}
+	return gConsole.Bold(gConsole.Yellow(mark)).String()
+}
+
+// Render the board with the highlighted squares and markers, the same layout as
+// the chess package. `--board-labels` places the coordinates and
+// `--board-border` draws the lines around and between the squares.
+func renderBoard(pos *chess.Position, blackSide bool, highlight map[chess.Square]bool, marks map[chess.Square]string) string {
+	board := pos.Board()
+	checked := chess.NoSquare
+	if gCheckHighlight {
+		checked = checkedKing(pos)
+	}
+	tableBuf := new(bytes.Buffer)
+	table := tablewriter.NewWriter(tableBuf)
+	table.SetAutoFormatHeaders(false)
+	switch gBoardBorder {
+	case "grid":
+		table.SetRowLine(true)
+	case "none":
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetColumnSeparator(" ")
+	}
+
+	top := strings.Contains(gBoardLabels, "top") || gBoardLabels == "both"
+	bottom := strings.Contains(gBoardLabels, "bottom") || gBoardLabels == "both"
+	left := strings.Contains(gBoardLabels, "left") || gBoardLabels == "both"
+	right := strings.Contains(gBoardLabels, "right") || gBoardLabels == "both"
+
+	files, ranks := "ABCDEFGH", "87654321"
+	if blackSide {
+		files, ranks = "HGFEDCBA", "12345678"
+	}
+	var header []string
+	if left {
+		header = append(header, "")
+	}
+	for i := range files {
+		header = append(header, files[i:i+1])
+	}
+	if right {
+		header = append(header, "")
+	}
+	if top {
+		table.SetHeader(header)
+	}
+	if bottom && gBoardBorder != "none" { // The footer merges empty cells into their neighbors.
+		footer := make([]string, len(header))
+		for i, label := range header {
+			footer[i] = label
+			if label == "" {
+				footer[i] = " "
+			}
+		}
+		table.SetFooter(footer)
+	}
+
+	if chess.ConsoleUnicode && gBoardBorder != "none" { // Enhance tablewriter with unicode lines.
+		table.SetCenterSeparator(gConsole.Gray(6, "┼").String())
+		table.SetColumnSeparator(gConsole.Gray(6, "│").String())
+		table.SetRowSeparator(gConsole.Gray(6, "─").String())
+	}
+
+	if chess.ConsoleColor {
+		labels := make([]tablewriter.Colors, len(header))
+		columns := make([]tablewriter.Colors, len(header))
+		for i := range header {
+			labels[i] = tablewriter.Colors{tablewriter.Normal, tablewriter.FgHiBlackColor}
+			columns[i] = tablewriter.Colors{tablewriter.Normal, tablewriter.Normal}
+		}
+		if left {
+			columns[0] = labels[0]
+		}
+		if right {
+			columns[len(columns)-1] = labels[0]
+		}
+		if top {
+			table.SetHeaderColor(labels...)
+		}
+		if bottom {
+			table.SetFooterColor(labels...)
+		}
+		table.SetColumnColor(columns...)
+	}
+
+	for i := 0; i < 8; i++ {
+		r := 7 - i
+		if blackSide {
+			r = i
+		}
+		var row []string
+		if left {
+			row = append(row, ranks[i:i+1])
+		}
+		for j := 0; j < 8; j++ {
+			f := j
+			if blackSide {
+				f = 7 - j
+			}
+			sq := chess.Square(r*8 + f)
+
+			cell := ""
+			if p := board.Piece(sq); p != chess.NoPiece {
+				cell = pieceCell(p.String())
+			}
+			if sq == checked {
+				cell = checkCell(cell, pos.Status() == chess.Checkmate)
+			} else if highlight[sq] {
+				cell = highlightCell(cell)
+			} else if cell == "" && gPalette == "cb" && !gNoColor && (r+f)%2 == 0 { // a1 is dark.
+				cell = gConsole.Faint("·").String()
+			} else if cell == "" && gBoardBorder == "none" { // Without lines the squares need a mark.
+				cell = gConsole.Faint("·").String()
+			}
+			if mark, ok := marks[sq]; ok {
+				cell += markColor(mark)
+			}
+			row = append(row, cell)
+		}
+		if right {
+			row = append(row, ranks[i:i+1])
+		}
+		table.Append(row)
+	}
+	if bottom && gBoardBorder == "none" { // The footer would bring its lines.
+		labels := make([]string, len(header))
+		for i, label := range header {
+			labels[i] = gConsole.Faint(label).String()
+		}
+		table.Append(labels)
+	}
+
+	table.Render()
+	return tableBuf.String()
+}
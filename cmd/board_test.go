@@ -0,0 +1,86 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+	"github.com/logrusorgru/aurora"
+)
+
+func TestLastMoveSquares(t *testing.T) {
+	tests := []struct {
+		name, fen, move string
+		want            []chess.Square
+	}{
+		{"no move yet", gStandardFEN, "", nil},
+		{"quiet move", gStandardFEN, "Nf3", []chess.Square{chess.G1, chess.F3}},
+		{"white castles short", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", []chess.Square{chess.E1, chess.G1, chess.H1, chess.F1}},
+		{"black castles long", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "O-O-O", []chess.Square{chess.E8, chess.C8, chess.A8, chess.D8}},
+		{"en passant", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "exf6", []chess.Square{chess.E5, chess.F6, chess.F5}},
+		{"black en passant", "rnbqkbnr/pp1ppppp/8/8/2pPP3/8/PPP2PPP/RNBQKBNR b KQkq d3 0 3", "cxd3", []chess.Square{chess.C4, chess.D3, chess.D4}},
+		{"promotion", "8/4P3/8/8/8/8/8/k6K w - - 0 1", "e8=Q", []chess.Square{chess.E7, chess.E8}},
+		{"capturing promotion", "3r4/4P3/8/8/8/8/8/k6K w - - 0 1", "exd8=N", []chess.Square{chess.E7, chess.D8}},
+	}
+	for _, tt := range tests {
+		game := testGame(t, tt.fen)
+		if tt.move != "" {
+			if err := game.MoveStr(tt.move); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+		var want map[chess.Square]bool
+		if tt.want != nil {
+			want = map[chess.Square]bool{}
+			for _, sq := range tt.want {
+				want[sq] = true
+			}
+		}
+		if got := lastMoveSquares(game); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: lastMoveSquares = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+// Check and mate look different on every palette.
+func TestCheckCell(t *testing.T) {
+	defer func(console aurora.Aurora, noColor bool, palette, marks string) {
+		gConsole, gNoColor, gPalette, gCheckMarks = console, noColor, palette, marks
+	}(gConsole, gNoColor, gPalette, gCheckMarks)
+	gCheckMarks = "+,#"
+	tests := []struct {
+		palette     string
+		noColor     bool
+		check, mate string
+	}{
+		{"default", false, "\x1b[41mK\x1b[0m", "\x1b[45mK\x1b[0m"},
+		{"cb", false, "\x1b[48;5;166mK+\x1b[0m", "\x1b[48;5;166mK#\x1b[0m"},
+		{"default", true, "K+", "K#"},
+	}
+	for _, tt := range tests {
+		gPalette, gNoColor = tt.palette, tt.noColor
+		gConsole = aurora.NewAurora(!tt.noColor)
+		if got := checkCell("K", false); got != tt.check {
+			t.Errorf("%s, no color %v: check %q, want %q", tt.palette, tt.noColor, got, tt.check)
+		}
+		if got := checkCell("K", true); got != tt.mate {
+			t.Errorf("%s, no color %v: mate %q, want %q", tt.palette, tt.noColor, got, tt.mate)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Named positions of a game by ply, kept next to the PGN like the eval cache.
+// The moves tell which bookmarks still belong to the game in the PGN.
+type bookmarkFile struct {
+	Moves     []string       `json:"moves"`
+	Bookmarks map[string]int `json:"bookmarks"`
+}
+
+// Bookmarks of the game played, saved with it.
+var gBookmarks map[string]int
+
+// The bookmark file of a PGN file, "game.pgn" keeps them in "game.bookmarks.json".
+func bookmarkPath(filename string) string {
+	return strings.TrimSuffix(filename, ".pgn") + ".bookmarks.json"
+}
+
+// Save the bookmarks of the game saved to the PGN file, if any.
+func saveBookmarks(game *chess.Game, filename string, bookmarks map[string]int) {
+	if len(bookmarks) == 0 {
+		return
+	}
+	data, err := json.MarshalIndent(bookmarkFile{Moves: uciMoves(game), Bookmarks: bookmarks}, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(bookmarkPath(filename), string(data)+"\n")
+	}
+	if err != nil {
+		fmt.Println("Unable to save the bookmarks to", gConsole.Bold(gConsole.Red(bookmarkPath(filename))).String()+",", err)
+	}
+}
+
+// Load the bookmarks saved with the PGN file. Those past a move that has
+// changed since are dropped.
+func loadBookmarks(game *chess.Game, filename string) map[string]int {
+	data, err := ioutil.ReadFile(bookmarkPath(filename))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Println("Unable to read the bookmarks,", err)
+		}
+		return nil
+	}
+	var saved bookmarkFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		fmt.Println(gConsole.Bold(gConsole.Red(bookmarkPath(filename))), "is not a bookmark file, ignored.")
+		return nil
+	}
+
+	moves := uciMoves(game)
+	same := 0 // Plies the game still shares with the bookmarks.
+	for same < len(moves) && same < len(saved.Moves) && moves[same] == saved.Moves[same] {
+		same++
+	}
+	bookmarks := map[string]int{}
+	for name, ply := range saved.Bookmarks {
+		if ply >= 0 && ply <= same {
+			bookmarks[name] = ply
+		}
+	}
+	return bookmarks
+}
+
+// List the bookmarks by ply.
+func printBookmarks(game *chess.Game, bookmarks map[string]int) {
+	if len(bookmarks) == 0 {
+		fmt.Println("No bookmarks yet, set one with", gConsole.Bold(gConsole.Yellow("bookmark <name>")).String()+".")
+		return
+	}
+	names := make([]string, 0, len(bookmarks))
+	for name := range bookmarks {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if bookmarks[names[i]] != bookmarks[names[j]] {
+			return bookmarks[names[i]] < bookmarks[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		fmt.Println(gConsole.Bold(gConsole.Yellow(name)), " ", bookmarkMove(game, bookmarks[name]))
+	}
+}
+
+// The move that led to the bookmarked ply, "12...Nf6", or the start.
+func bookmarkMove(game *chess.Game, ply int) string {
+	if ply == 0 {
+		return "start of the game"
+	}
+	before := rewindGame(game, ply-1)
+	return moveNumber(before.Position()) + moveSAN(before, game.Moves()[ply-1])
+}
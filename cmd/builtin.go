@@ -0,0 +1,267 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Built-in engine searching shallow minimax, `--engine builtin`. Far weaker
+// than a real engine, but Piñata plays without one installed.
+const (
+	gBuiltinEngine   = "Builtin"
+	gBuiltinMaxDepth = 3 // Deeper takes too long without a real engine's tricks.
+	gBuiltinMate     = 100000
+)
+
+func isBuiltinEngine(path string) bool {
+	return strings.EqualFold(path, gBuiltinEngine)
+}
+
+// Search of a built-in engine, the move to play with the "go" parameters. nil
+// when there is none.
+type builtinSearch func(pos *chess.Position, params string) *chess.Move
+
+// Start a built-in engine in the background and complete the UCI handshake.
+func newBuiltinEngine(name string, search builtinSearch) (*uciEngine, error) {
+	engineIn, toEngine := io.Pipe()
+	fromEngine, engineOut := io.Pipe()
+	go serveUCI(engineIn, engineOut, name, search)
+
+	eng := &uciEngine{Options: make(map[string]uciOption)}
+	eng.stdin = bufio.NewWriter(toEngine)
+	eng.stdout = bufio.NewReader(fromEngine)
+	if err := eng.handshake(); err != nil {
+		return nil, err
+	}
+	return eng, nil
+}
+
+// Answer the UCI commands of a built-in engine until "quit".
+func serveUCI(in io.Reader, out *io.PipeWriter, name string, search builtinSearch) {
+	defer out.Close()
+	game := chess.NewGame()
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Fprintln(out, "id name "+name)
+			fmt.Fprintln(out, "id author Piñata")
+			fmt.Fprintln(out, "uciok")
+		case "isready":
+			fmt.Fprintln(out, "readyok")
+		case "position":
+			game = builtinPosition(fields[1:])
+		case "go":
+			move := search(game.Position(), strings.Join(fields[1:], " "))
+			if move == nil {
+				fmt.Fprintln(out, "bestmove (none)")
+				continue
+			}
+			fmt.Fprintln(out, "bestmove "+move.String())
+		case "quit":
+			return
+		}
+	}
+}
+
+// Game of "position startpos moves e2e4" or "position fen ... moves e2e4".
+func builtinPosition(fields []string) *chess.Game {
+	game := chess.NewGame()
+	moves := 0
+	for i, field := range fields {
+		if field == "moves" {
+			moves = i + 1
+			break
+		}
+	}
+	if len(fields) > 1 && fields[0] == "fen" {
+		end := len(fields)
+		if moves > 0 {
+			end = moves - 1
+		}
+		if fen, err := chess.FEN(strings.Join(fields[1:end], " ")); err == nil {
+			game = chess.NewGame(fen)
+		}
+	}
+	if moves > 0 {
+		for _, lan := range fields[moves:] {
+			move, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), lan)
+			if err != nil || game.Move(move) != nil {
+				break
+			}
+		}
+	}
+	return game
+}
+
+// Best move by minimax to the "go depth", at most gBuiltinMaxDepth.
+func minimaxMove(pos *chess.Position, params string) *chess.Move {
+	depth := 2 // When searching by time or nodes.
+	fields := strings.Fields(params)
+	for i := 0; i+1 < len(fields); i++ {
+		if fields[i] == "depth" {
+			depth, _ = strconv.Atoi(fields[i+1])
+		}
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	if depth > gBuiltinMaxDepth {
+		depth = gBuiltinMaxDepth
+	}
+
+	var best *chess.Move
+	alpha := -gBuiltinMate - 1
+	for _, move := range orderMoves(pos.ValidMoves()) {
+		score := -negamax(pos.Update(move), depth-1, -gBuiltinMate-1, -alpha, 1)
+		if best == nil || score > alpha {
+			best, alpha = move, score
+		}
+	}
+	return best
+}
+
+// Score of the position for the side to move, with alpha-beta pruning.
+// Nearer mates score higher.
+func negamax(pos *chess.Position, depth, alpha, beta, ply int) int {
+	moves := pos.ValidMoves()
+	if len(moves) == 0 {
+		if pos.Status() == chess.Checkmate {
+			return -gBuiltinMate + ply
+		}
+		return 0 // Stalemate.
+	}
+	if depth == 0 {
+		score := evaluate(pos.Board())
+		if pos.Turn() == chess.Black {
+			return -score
+		}
+		return score
+	}
+
+	for _, move := range orderMoves(moves) {
+		score := -negamax(pos.Update(move), depth-1, -beta, -alpha, ply+1)
+		if score >= beta {
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return alpha
+}
+
+// Captures first, they prune the most.
+func orderMoves(moves []*chess.Move) []*chess.Move {
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].HasTag(chess.Capture) && !moves[j].HasTag(chess.Capture)
+	})
+	return moves
+}
+
+// Material and piece placement from White's side, in centipawns.
+func evaluate(board *chess.Board) int {
+	score := 0
+	for sq, piece := range board.SquareMap() {
+		row := 7 - int(sq.Rank()) // The tables read from White's side, 8th rank first.
+		if piece.Color() == chess.Black {
+			row = int(sq.Rank())
+		}
+		value := 100*gPieceValues[piece.Type()] + gPieceSquares[piece.Type()][row][sq.File()]
+		if piece.Color() == chess.Black {
+			value = -value
+		}
+		score += value
+	}
+	return score
+}
+
+// Simplified piece-square tables by Tomasz Michniewski.
+var gPieceSquares = map[chess.PieceType][8][8]int{
+	chess.Pawn: {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{50, 50, 50, 50, 50, 50, 50, 50},
+		{10, 10, 20, 30, 30, 20, 10, 10},
+		{5, 5, 10, 25, 25, 10, 5, 5},
+		{0, 0, 0, 20, 20, 0, 0, 0},
+		{5, -5, -10, 0, 0, -10, -5, 5},
+		{5, 10, 10, -20, -20, 10, 10, 5},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	},
+	chess.Knight: {
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+		{-40, -20, 0, 0, 0, 0, -20, -40},
+		{-30, 0, 10, 15, 15, 10, 0, -30},
+		{-30, 5, 15, 20, 20, 15, 5, -30},
+		{-30, 0, 15, 20, 20, 15, 0, -30},
+		{-30, 5, 10, 15, 15, 10, 5, -30},
+		{-40, -20, 0, 5, 5, 0, -20, -40},
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+	},
+	chess.Bishop: {
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 10, 10, 5, 0, -10},
+		{-10, 5, 5, 10, 10, 5, 5, -10},
+		{-10, 0, 10, 10, 10, 10, 0, -10},
+		{-10, 10, 10, 10, 10, 10, 10, -10},
+		{-10, 5, 0, 0, 0, 0, 5, -10},
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+	},
+	chess.Rook: {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{5, 10, 10, 10, 10, 10, 10, 5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{0, 0, 0, 5, 5, 0, 0, 0},
+	},
+	chess.Queen: {
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 5, 5, 5, 0, -10},
+		{-5, 0, 5, 5, 5, 5, 0, -5},
+		{0, 0, 5, 5, 5, 5, 0, -5},
+		{-10, 5, 5, 5, 5, 5, 0, -10},
+		{-10, 0, 5, 0, 0, 0, 0, -10},
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+	},
+	chess.King: { // Middle game, stay sheltered.
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-20, -30, -30, -40, -40, -30, -30, -20},
+		{-10, -20, -20, -20, -20, -20, -20, -10},
+		{20, 20, 0, 0, 0, 0, 20, 20},
+		{20, 30, 10, 0, 0, 10, 30, 20},
+	},
+}
@@ -0,0 +1,221 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Chess clock with Fischer increment. With time odds the sides play on
+// different time controls.
+type chessClock struct {
+	white    time.Duration
+	black    time.Duration
+	whiteInc time.Duration
+	blackInc time.Duration
+	whiteTC  string // Time controls for the PGN, "300+3".
+	blackTC  string
+	running  chess.Color // NoColor when stopped.
+	started  time.Time
+	paused   bool // The running side's time stands still.
+}
+
+// Parse a time control of the form "minutes+increment", e.g. "5+3" or "10".
+func newChessClock(tc string) (*chessClock, error) {
+	c := &chessClock{}
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		if err := c.SetTimeControl(color, tc); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Give the side its own time control, "minutes+increment", for time odds.
+func (c *chessClock) SetTimeControl(color chess.Color, tc string) error {
+	parts := strings.SplitN(tc, "+", 2)
+	minutes, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || minutes <= 0 {
+		return errors.New("invalid time control " + strconv.Quote(tc))
+	}
+	increment := 0.0
+	if len(parts) == 2 {
+		increment, err = strconv.ParseFloat(parts[1], 64)
+		if err != nil || increment < 0 {
+			return errors.New("invalid increment in time control " + strconv.Quote(tc))
+		}
+	}
+
+	base := time.Duration(minutes * float64(time.Minute))
+	inc := time.Duration(increment * float64(time.Second))
+	pgnTC := strconv.FormatInt(int64(base.Seconds()), 10) + "+" + strconv.FormatInt(int64(inc.Seconds()), 10)
+	if color == chess.White {
+		c.white, c.whiteInc, c.whiteTC = base, inc, pgnTC
+	} else {
+		c.black, c.blackInc, c.blackTC = base, inc, pgnTC
+	}
+	return nil
+}
+
+// Set up the clock from `--clock` and `--time-odds`, e.g. "white=5+0 black=2+0".
+// Sides without odds play on the `--clock`.
+func setupClock(tc, odds string) (*chessClock, error) {
+	controls := map[chess.Color]string{chess.White: tc, chess.Black: tc}
+	for _, side := range strings.FieldsFunc(odds, func(r rune) bool { return r == ' ' || r == ',' }) {
+		kv := strings.SplitN(side, "=", 2)
+		switch {
+		case len(kv) == 2 && strings.EqualFold(kv[0], "white"):
+			controls[chess.White] = kv[1]
+		case len(kv) == 2 && strings.EqualFold(kv[0], "black"):
+			controls[chess.Black] = kv[1]
+		default:
+			return nil, errors.New("invalid time odds " + strconv.Quote(side) + ", write them like white=5+0")
+		}
+	}
+	if controls[chess.White] == "" || controls[chess.Black] == "" {
+		return nil, errors.New("time odds need a time control for both sides, or a --clock for the other")
+	}
+
+	c := &chessClock{}
+	for color, control := range controls {
+		if err := c.SetTimeControl(color, control); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Time controls of the sides for the PGN.
+func (c *chessClock) TimeControls() (white, black string) {
+	return c.whiteTC, c.blackTC
+}
+
+// Start the clock of the given side, if not already running.
+func (c *chessClock) Start(color chess.Color) {
+	if c.running == color {
+		return
+	}
+	c.Stop()
+	c.running = color
+	c.started = time.Now()
+}
+
+// Stop the running clock, deduct the time spent and add the increment. A paused
+// clock has been charged already.
+func (c *chessClock) Stop() {
+	if c.running == chess.NoColor {
+		return
+	}
+	spent := time.Since(c.started)
+	if c.paused {
+		spent = 0
+	}
+	if c.running == chess.White {
+		c.white += c.whiteInc - spent
+	} else {
+		c.black += c.blackInc - spent
+	}
+	c.running, c.paused = chess.NoColor, false
+}
+
+// Pause the running clock, the time until Resume does not count.
+func (c *chessClock) Pause() {
+	if c.running == chess.NoColor || c.paused {
+		return
+	}
+	spent := time.Since(c.started)
+	if c.running == chess.White {
+		c.white -= spent
+	} else {
+		c.black -= spent
+	}
+	c.paused = true
+}
+
+// Resume the paused clock.
+func (c *chessClock) Resume() {
+	if !c.paused {
+		return
+	}
+	c.started = time.Now()
+	c.paused = false
+}
+
+// Is the clock paused?
+func (c *chessClock) Paused() bool {
+	return c.paused
+}
+
+// Remaining time of the given side, including the time ticking away right now.
+func (c *chessClock) Remaining(color chess.Color) time.Duration {
+	left := c.black
+	if color == chess.White {
+		left = c.white
+	}
+	if c.running == color && !c.paused {
+		left -= time.Since(c.started)
+	}
+	return left
+}
+
+// Has the given side run out of time?
+func (c *chessClock) Flagged(color chess.Color) bool {
+	return c.Remaining(color) <= 0
+}
+
+// UCI "go" parameters for the engine playing with this clock. The engine is
+// told how many moves to budget for, so it spends a fraction of its remaining
+// time plus the increment on every move.
+func (c *chessClock) GoParams(movesToGo int) string {
+	return fmt.Sprintf("wtime %d btime %d winc %d binc %d movestogo %d",
+		c.white.Milliseconds(), c.black.Milliseconds(),
+		c.whiteInc.Milliseconds(), c.blackInc.Milliseconds(), movesToGo)
+}
+
+// Format the remaining time as m:ss.
+func (c *chessClock) String(color chess.Color) string {
+	left := c.Remaining(color)
+	if left < 0 {
+		left = 0
+	}
+	left = left.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(left.Minutes()), int(left.Seconds())%60)
+}
+
+// The side ran out of time and loses the game.
+func flagFall(game *chess.Game, color chess.Color) {
+	fmt.Println(gConsole.Bold(gConsole.Red(color.Name())).String() + " ran out of time.")
+	game.Resign(color)
+	game.AddTagPair("Termination", "time forfeit")
+}
+
+// Moves the engine budgets its remaining time for, based on the `--tc-style`.
+func tcMovesToGo() int {
+	switch gTCStyle {
+	case "aggressive": // Think longer now, trust the increment later.
+		return 20
+	case "conservative": // Keep a reserve for the endgame.
+		return 40
+	}
+	return 30
+}
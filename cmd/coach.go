@@ -0,0 +1,171 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/abperiasamy/chess"
+)
+
+const (
+	gCoachLines  = 5   // Candidate moves the engine is asked for.
+	gCoachMargin = 50  // Centipawns behind the best move that still make a good move.
+	gOnlyMoveGap = 150 // Centipawns the next best move is behind an only move.
+)
+
+// Search the position for the engine's best lines.
+func bestLines(engine *uciEngine, game *chess.Game, lines int) (*uciResults, error) {
+	if engine.HasOption("MultiPV") {
+		engine.SendOption("MultiPV", lines)
+		defer engine.SendOption("MultiPV", 1)
+	}
+
+	depth := gEngineDepth
+	if depth < 1 { // Coaching is not charged to the clock.
+		depth = 10
+	}
+	engine.SetFEN(game.FEN())
+	results, err := engine.Go("depth " + strconv.Itoa(depth))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := results.Best(); !ok {
+		return nil, errors.New("the engine did not report an evaluation")
+	}
+	return results, nil
+}
+
+// Moves within the coaching margin of the engine's best move, best first.
+// Scores are from the side to move.
+func goodMoves(engine *uciEngine, game *chess.Game) ([]uciInfo, error) {
+	results, err := bestLines(engine, game, gCoachLines)
+	if err != nil {
+		return nil, err
+	}
+	best, _ := results.Best()
+
+	var good []uciInfo
+	for _, line := range results.Lines {
+		if len(line.PV) > 0 && closeToBest(best, line) {
+			good = append(good, line)
+		}
+	}
+	return good, nil
+}
+
+// Check whether the line is about as good as the best line.
+func closeToBest(best, line uciInfo) bool {
+	switch {
+	case best.Mate && best.Score > 0: // Any forced mate will do.
+		return line.Mate && line.Score > 0
+	case best.Mate || line.Mate:
+		return best.Mate == line.Mate && best.Score == line.Score
+	}
+	return best.Score-line.Score <= gCoachMargin
+}
+
+// Format the score in pawns, or moves to mate.
+func formatScore(info uciInfo) string {
+	if info.Mate {
+		return "#" + strconv.Itoa(info.Score)
+	}
+	return fmt.Sprintf("%+.2f", float64(info.Score)/100)
+}
+
+// Print all the good moves in the position with their evaluation.
+func coach(engine *uciEngine, game *chess.Game) {
+	good, err := goodMoves(engine, game)
+	if err != nil {
+		fmt.Println("Unable to coach,", err)
+		return
+	}
+
+	if len(good) == 1 {
+		fmt.Println("There is only one good move here.")
+	} else {
+		fmt.Println("These", len(good), "moves are all fine.")
+	}
+	for _, line := range good {
+		move, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), line.PV[0])
+		if err != nil {
+			continue
+		}
+		fmt.Printf("  %-8s %s\n", gConsole.Bold(gConsole.Yellow(showMove(game, move))), formatScore(line))
+	}
+}
+
+// The human's position looked into with `--only-moves`, and its only move if any.
+var gOnlyMove struct {
+	fen  string
+	move *chess.Move
+}
+
+// The one move that holds the position, the next best falling far behind.
+// None if there are several or it is the only legal move anyway.
+func onlyMove(engine *uciEngine, game *chess.Game) *chess.Move {
+	results, err := bestLines(engine, game, 2)
+	if err != nil || len(results.Lines) < 2 || len(results.Lines[0].PV) == 0 {
+		return nil
+	}
+	best, next := results.Lines[0], results.Lines[1]
+	switch {
+	case best.Mate && best.Score > 0: // Only one way to mate.
+		if next.Mate && next.Score > 0 {
+			return nil
+		}
+	case best.Mate: // Mated whatever the move.
+		return nil
+	case next.Mate && next.Score < 0: // Every other move gets mated.
+	case next.Mate:
+		return nil
+	case best.Score-next.Score < gOnlyMoveGap:
+		return nil
+	}
+	move, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), best.PV[0])
+	if err != nil {
+		return nil
+	}
+	return move
+}
+
+// Look for an only move before the human moves. With `--only-moves before` the
+// human is told right away, without giving the move away.
+func checkOnlyMove(engine *uciEngine, game *chess.Game) {
+	if gOnlyMoves == "" || engine == nil || game.FEN() == gOnlyMove.fen || game.Outcome() != chess.NoOutcome {
+		return
+	}
+	gOnlyMove.fen, gOnlyMove.move = game.FEN(), onlyMove(engine, game)
+	if gOnlyMove.move != nil && gOnlyMoves == "before" {
+		fmt.Println(gConsole.Bold(gConsole.Yellow("Only move:")).String(), "one move holds the position here, the rest fall far behind.")
+	}
+}
+
+// With `--only-moves after`, tell whether the human's move about to be played
+// is the only move.
+func reportOnlyMove(game *chess.Game, move *chess.Move) {
+	if gOnlyMoves != "after" || gOnlyMove.move == nil || game.FEN() != gOnlyMove.fen {
+		return
+	}
+	if move.String() == gOnlyMove.move.String() {
+		fmt.Println(gConsole.Bold(gConsole.Green("Only move, well found!")))
+		return
+	}
+	fmt.Println(gConsole.Bold(gConsole.Red("Missed the only move")).String()+",", showMove(game, gOnlyMove.move)+".")
+}
@@ -0,0 +1,142 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Config keys without a command-line flag.
+var gConfigKeys = map[string]*string{
+	"prompt": &gPromptTemplate,
+}
+
+// Load the TOML config file. Keys are named after the long flags, e.g.
+// `engine = "stockfish"`, and command-line flags override them. The default
+// config file is optional.
+func loadConfig(cmd *cobra.Command) {
+	file, err := os.Open(gCfgFile)
+	if err != nil {
+		if os.IsNotExist(err) && !cmd.Flags().Changed("config") {
+			return
+		}
+		fmt.Println("Unable to read the config file", gCfgFile+":", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	lineNum := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNum++
+		key, value, err := parseConfigLine(scanner.Text())
+		if err == nil && key != "" {
+			err = setConfig(cmd, key, value)
+		}
+		if err != nil {
+			fmt.Printf("%s:%d: %v\n", gCfgFile, lineNum, err)
+			os.Exit(1)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Println("Unable to read the config file", gCfgFile+":", err)
+		os.Exit(1)
+	}
+}
+
+// Add a setting to the end of the config file, creating it if need be.
+func appendConfig(key, value string) error {
+	dat, err := ioutil.ReadFile(gCfgFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	text := string(dat)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return writeFileAtomic(gCfgFile, text+key+" = "+strconv.Quote(value)+"\n")
+}
+
+// Parse a `key = value` line. Blank and comment lines return an empty key.
+func parseConfigLine(line string) (string, string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", nil
+	}
+
+	i := strings.Index(line, "=")
+	if i < 0 {
+		return "", "", fmt.Errorf("expected key = value, got %q", line)
+	}
+	key := strings.TrimSpace(line[:i])
+	value := strings.TrimSpace(line[i+1:])
+
+	if strings.HasPrefix(value, `"`) { // Basic string, may contain '#'.
+		end := strings.LastIndex(value, `"`)
+		rest := strings.TrimSpace(value[end+1:])
+		if end == 0 || (rest != "" && !strings.HasPrefix(rest, "#")) {
+			return "", "", fmt.Errorf("malformed string for %s", key)
+		}
+		unquoted, err := strconv.Unquote(value[:end+1])
+		if err != nil {
+			return "", "", fmt.Errorf("malformed string for %s", key)
+		}
+		return key, unquoted, nil
+	}
+
+	if i := strings.Index(value, "#"); i >= 0 { // Trailing comment.
+		value = strings.TrimSpace(value[:i])
+	}
+	return key, value, nil
+}
+
+// Apply a config setting unless its flag is given on the command-line.
+func setConfig(cmd *cobra.Command, key, value string) error {
+	if setting, ok := gConfigKeys[key]; ok {
+		*setting = value
+		return nil
+	}
+	if strings.HasPrefix(key, "bind.") {
+		return setBinding(strings.TrimPrefix(key, "bind."), value)
+	}
+	if strings.HasPrefix(key, "start.") {
+		return setStartPosition(strings.TrimPrefix(key, "start."), value)
+	}
+	if strings.HasPrefix(key, "profile.") {
+		return setProfile(cmd, strings.TrimPrefix(key, "profile."), value)
+	}
+
+	flag := cmd.Flags().Lookup(key)
+	if flag == nil || key == "config" {
+		return fmt.Errorf("unknown config key %s", key)
+	}
+	if flag.Changed {
+		return nil
+	}
+	if err := cmd.Flags().Set(key, value); err != nil {
+		return fmt.Errorf("invalid value %q for %s", value, key)
+	}
+	gConfigFlags[key] = true
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/abperiasamy/chess"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gMovePGN string
+	gMoveSAN string
+)
+
+// moveCmd plays one move of a correspondence game between two humans. Each
+// player adds their move to the PGN and sends the file on to the other.
+var moveCmd = &cobra.Command{
+	Use:   "move",
+	Short: "Play a move of a correspondence game kept in a PGN file",
+	Example: `  pinata move --pgn game.pgn --san e4 --white-name Ann --black-name Bob
+  pinata move --pgn game.pgn --san e5`,
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		if gMovePGN == "" || gMoveSAN == "" {
+			fmt.Println("Name the game with --pgn and the move with --san.")
+			os.Exit(1)
+		}
+		gTwoPlayer = true // No engine, the players take turns by file.
+
+		// The first move starts the game, from `--fen` or `--start` if given.
+		game := newGame()
+		date := ""
+		if _, err := os.Stat(gMovePGN); err == nil {
+			// Saving one game back would lose the others of a database.
+			if games, err := pgnGames(gMovePGN); err == nil && len(games) > 1 {
+				fmt.Println(gConsole.Bold(gConsole.Red(gMovePGN)), "holds", len(games), "games, move plays a single game.")
+				os.Exit(1)
+			}
+			if game = readPGN(nil, gMovePGN); game == nil {
+				os.Exit(1)
+			}
+			restoreName(&gWhiteName, GetTagPair(game, "White"))
+			restoreName(&gBlackName, GetTagPair(game, "Black"))
+			date = GetTagPair(game, "Date")
+		}
+		if isGameOver(game) {
+			os.Exit(1)
+		}
+
+		move, err := decodeMove(game, gMoveSAN)
+		if err != nil {
+			fmt.Println(gConsole.Bold(gConsole.Red(gMoveSAN)), "is not a legal move for", game.Position().Turn().Name()+".")
+			printMoveError(game, err)
+			os.Exit(1)
+		}
+		mover := game.Position().Turn()
+		san := encodeSAN(game.Position(), move)
+		game.Move(move)
+
+		tagGame(game)
+		if date != "" { // The date the game started.
+			game.AddTagPair("Date", date)
+		}
+		if game.Outcome() == chess.NoOutcome {
+			game.AddTagPair("ToMove", game.Position().Turn().Name())
+		} else {
+			game.RemoveTagPair("ToMove")
+		}
+		if !dryRun(gMovePGN) {
+			if err := writePGN(game, gMovePGN); err != nil {
+				fmt.Println("Unable to save the game to", gConsole.Bold(gConsole.Red(gMovePGN)).String()+",", err)
+				os.Exit(1)
+			}
+		}
+
+		fmt.Println(playerName(mover), "played", gConsole.Bold(gConsole.Yellow(san)).String()+".")
+		if !isGameOver(game) {
+			next := game.Position().Turn()
+			fmt.Println(next.Name(), "to move, send", gConsole.Bold(gMovePGN).String(), "to", playerName(next)+".")
+		}
+	},
+}
+
+func init() {
+	moveCmd.Flags().StringVar(&gMovePGN, "pgn", "", "PGN file of the correspondence game, created by the first move")
+	moveCmd.Flags().StringVar(&gMoveSAN, "san", "", "the move to play, e.g. Nf3 or g1f3")
+	rootCmd.AddCommand(moveCmd)
+}
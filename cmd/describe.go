@@ -0,0 +1,201 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Conventional piece values in pawns.
+var gPieceValues = map[chess.PieceType]int{
+	chess.Queen: 9, chess.Rook: 5, chess.Bishop: 3, chess.Knight: 3, chess.Pawn: 1,
+}
+
+var gPieceNames = []struct {
+	kind chess.PieceType
+	name string
+}{
+	{chess.Queen, "queen"}, {chess.Rook, "rook"}, {chess.Bishop, "bishop"}, {chess.Knight, "knight"}, {chess.Pawn, "pawn"},
+}
+
+// Name of the piece type, "knight".
+func pieceTypeName(kind chess.PieceType) string {
+	for _, n := range gPieceNames {
+		if n.kind == kind {
+			return n.name
+		}
+	}
+	return "king"
+}
+
+// Print a rough, human readable summary of the position for study. These are
+// simple heuristics, not an evaluation.
+func describePosition(board *chess.Board) {
+	squares := board.SquareMap()
+	pawns := map[chess.Color][8][]int{} // Ranks of the pawns on every file.
+	kings := map[chess.Color]chess.Square{}
+	for sq, p := range squares {
+		switch p.Type() {
+		case chess.Pawn:
+			files := pawns[p.Color()]
+			files[sq.File()] = append(files[sq.File()], int(sq.Rank()))
+			pawns[p.Color()] = files
+		case chess.King:
+			kings[p.Color()] = sq
+		}
+	}
+
+	fmt.Println(gConsole.Bold("Position summary").String(), gConsole.Faint("(approximate)"))
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		fmt.Println(gConsole.Bold(gConsole.Yellow(color.Name())).String()+":", materialSummary(squares, color))
+		for _, note := range pawnNotes(pawns, color) {
+			fmt.Println("  " + note)
+		}
+	}
+
+	// King safety: pawns sheltering the king against enemy pieces close to it.
+	safety := map[chess.Color]int{}
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		safety[color] = kingSafety(squares, kings[color], color)
+	}
+	switch {
+	case safety[chess.White] > safety[chess.Black]:
+		fmt.Println("White's king looks safer.")
+	case safety[chess.White] < safety[chess.Black]:
+		fmt.Println("Black's king looks safer.")
+	default:
+		fmt.Println("Both kings look about equally safe.")
+	}
+
+	var open []string
+	for f := 0; f < 8; f++ {
+		if len(pawns[chess.White][f]) == 0 && len(pawns[chess.Black][f]) == 0 {
+			open = append(open, string(rune('a'+f)))
+		}
+	}
+	if len(open) > 0 {
+		fmt.Println("Open files:", strings.Join(open, " "))
+	} else {
+		fmt.Println("No open files.")
+	}
+}
+
+// Pieces and material points of the side.
+func materialSummary(squares map[chess.Square]chess.Piece, color chess.Color) string {
+	counts := map[chess.PieceType]int{}
+	points := 0
+	for _, p := range squares {
+		if p.Color() == color {
+			counts[p.Type()]++
+			points += gPieceValues[p.Type()]
+		}
+	}
+
+	var parts []string
+	for _, piece := range gPieceNames {
+		if n := counts[piece.kind]; n == 1 {
+			parts = append(parts, "1 "+piece.name)
+		} else if n > 1 {
+			parts = append(parts, strconv.Itoa(n)+" "+piece.name+"s")
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "bare king")
+	}
+	return strings.Join(parts, ", ") + " (" + strconv.Itoa(points) + " points)"
+}
+
+// Doubled, isolated and passed pawns of the side.
+func pawnNotes(pawns map[chess.Color][8][]int, color chess.Color) []string {
+	own, their := pawns[color], pawns[color.Other()]
+	var doubled, isolated, passed []string
+	for f := 0; f < 8; f++ {
+		file := string(rune('a' + f))
+		if len(own[f]) > 1 {
+			doubled = append(doubled, file)
+		}
+		if len(own[f]) > 0 && (f == 0 || len(own[f-1]) == 0) && (f == 7 || len(own[f+1]) == 0) {
+			isolated = append(isolated, file)
+		}
+
+		for _, rank := range own[f] {
+			if isPassed(their, f, rank, color) {
+				passed = append(passed, file+strconv.Itoa(rank+1))
+			}
+		}
+	}
+
+	var notes []string
+	if len(doubled) > 0 {
+		notes = append(notes, "doubled pawns on "+fileList(doubled))
+	}
+	if len(isolated) > 0 {
+		notes = append(notes, "isolated pawns on "+fileList(isolated))
+	}
+	if len(passed) > 0 {
+		notes = append(notes, "passed pawns on "+strings.Join(passed, " "))
+	}
+	return notes
+}
+
+// Name the files, e.g. "the a and c files".
+func fileList(files []string) string {
+	if len(files) == 1 {
+		return "the " + files[0] + " file"
+	}
+	return "the " + strings.Join(files[:len(files)-1], ", ") + " and " + files[len(files)-1] + " files"
+}
+
+// No enemy pawn ahead of the pawn on its own or the neighboring files.
+func isPassed(their [8][]int, file, rank int, color chess.Color) bool {
+	for f := file - 1; f <= file+1; f++ {
+		if f < 0 || f > 7 {
+			continue
+		}
+		for _, r := range their[f] {
+			if (color == chess.White && r > rank) || (color == chess.Black && r < rank) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Own pawns right in front of the king, less enemy pieces within two squares of it.
+func kingSafety(squares map[chess.Square]chess.Piece, king chess.Square, color chess.Color) int {
+	ahead := 1
+	if color == chess.Black {
+		ahead = -1
+	}
+
+	score := 0
+	kf, kr := int(king.File()), int(king.Rank())
+	for sq, p := range squares {
+		df, dr := int(sq.File())-kf, int(sq.Rank())-kr
+		if p.Color() == color && p.Type() == chess.Pawn && abs(df) <= 1 && (dr == ahead || dr == 2*ahead) {
+			score++
+		}
+		if p.Color() != color && p.Type() != chess.Pawn && p.Type() != chess.King && abs(df) <= 2 && abs(dr) <= 2 {
+			score -= 2
+		}
+	}
+	return score
+}
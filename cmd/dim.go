@@ -0,0 +1,90 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"github.com/logrusorgru/aurora"
+)
+
+// Muted shades of `--dim`, 256-color indices.
+const (
+	gDimRed        = 131
+	gDimGreen      = 108
+	gDimYellow     = 143
+	gDimCyan       = 73
+	gDimMagenta    = 139
+	gDimHighlight  = 58  // Dark olive for the last move, in place of brown.
+	gDimCheck      = 52  // Dark red for the king in check.
+	gDimPiece      = 250 // Light gray pieces on a dark background.
+	gDimPieceLight = 240 // Dark gray ones on a light background, `--light`.
+)
+
+// Darker shades of the colors the `--palette` picks by index.
+var gDimIndex = map[uint8]uint8{
+	gPaletteCBHighlight: 130,
+	gPaletteCBMark:      31,
+	gPaletteCBCheck:     124,
+}
+
+// Colors of `--dim` for long sessions at night: no bold, muted shades in
+// place of the bright ones and grayed pieces. The pieces keep their shapes so
+// the sides stay apart, and `--palette` still applies, in darker shades.
+type dimAurora struct {
+	aurora.Aurora
+}
+
+// The text as it is, colored or not.
+func (a dimAurora) plain(arg interface{}) aurora.Value {
+	if value, ok := arg.(aurora.Value); ok {
+		return value
+	}
+	return a.Aurora.Reset(arg)
+}
+
+func (a dimAurora) Bold(arg interface{}) aurora.Value    { return a.plain(arg) }
+func (a dimAurora) Red(arg interface{}) aurora.Value     { return a.Aurora.Index(gDimRed, arg) }
+func (a dimAurora) Green(arg interface{}) aurora.Value   { return a.Aurora.Index(gDimGreen, arg) }
+func (a dimAurora) Yellow(arg interface{}) aurora.Value  { return a.Aurora.Index(gDimYellow, arg) }
+func (a dimAurora) Cyan(arg interface{}) aurora.Value    { return a.Aurora.Index(gDimCyan, arg) }
+func (a dimAurora) Magenta(arg interface{}) aurora.Value { return a.Aurora.Index(gDimMagenta, arg) }
+func (a dimAurora) BgBrown(arg interface{}) aurora.Value { return a.Aurora.BgIndex(gDimHighlight, arg) }
+func (a dimAurora) BgRed(arg interface{}) aurora.Value   { return a.Aurora.BgIndex(gDimCheck, arg) }
+
+func (a dimAurora) Index(n uint8, arg interface{}) aurora.Value {
+	if dim, ok := gDimIndex[n]; ok {
+		n = dim
+	}
+	return a.Aurora.Index(n, arg)
+}
+
+func (a dimAurora) BgIndex(n uint8, arg interface{}) aurora.Value {
+	if dim, ok := gDimIndex[n]; ok {
+		n = dim
+	}
+	return a.Aurora.BgIndex(n, arg)
+}
+
+// A piece on the board, grayed with `--dim`.
+func pieceCell(piece string) string {
+	if !gDim || gNoColor {
+		return piece
+	}
+	if gLightBg {
+		return gConsole.Index(gDimPieceLight, piece).String()
+	}
+	return gConsole.Index(gDimPiece, piece).String()
+}
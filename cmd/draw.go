@@ -0,0 +1,132 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"github.com/abperiasamy/chess"
+)
+
+const (
+	gSyzygyMaxPieces = 7  // Largest positions covered by the Syzygy tablebases.
+	gLevelScore      = 20 // Centipawns either way that still count as a level position.
+)
+
+// Why the position is a dead draw, or "" if it is still worth playing on.
+// The check is opt-in, see `--dead-draws`.
+func deadDrawReason(game *chess.Game) string {
+	if game.Outcome() != chess.NoOutcome {
+		return ""
+	}
+	if gDeadDraws && drawnMaterial(game.Position().Board()) {
+		return "drawn material"
+	}
+	return ""
+}
+
+// Check whether the engine's last search, which the game followed, found the
+// position in the tablebases and drawn (WDL = 0). A level score alone is no
+// draw, the engine must have hit the tablebases on its way. Opt-in with `--syzygy`.
+func tablebaseDraw(game *chess.Game, info *uciInfo) bool {
+	if gSyzygyPath == "" || info == nil || game.Outcome() != chess.NoOutcome {
+		return false
+	}
+	moves := game.Moves()
+	if len(moves) == 0 || len(info.PV) == 0 || moves[len(moves)-1].String() != info.PV[0] {
+		return false // The search was for another position.
+	}
+	if len(game.Position().Board().SquareMap()) > gSyzygyMaxPieces {
+		return false
+	}
+	return info.TBHits > 0 && !info.Mate && !info.Lowerbound && !info.Upperbound && info.Score == 0
+}
+
+// No pawns or major pieces, and neither side has enough minor pieces to force a mate.
+// The chess package already adjudicates the insufficient material cases.
+func drawnMaterial(board *chess.Board) bool {
+	minors := map[chess.Color]int{}
+	knights := map[chess.Color]int{}
+	for _, p := range board.SquareMap() {
+		switch p.Type() {
+		case chess.Queen, chess.Rook, chess.Pawn:
+			return false
+		case chess.Knight:
+			knights[p.Color()]++
+			minors[p.Color()]++
+		case chess.Bishop:
+			minors[p.Color()]++
+		}
+	}
+
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		if minors[color] <= 1 {
+			continue
+		}
+		// Two knights cannot force a mate against a bare king.
+		if minors[color] == 2 && knights[color] == 2 && minors[color.Other()] == 0 {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// Check whether the engine's evaluation stayed level over its last `--draw-offers` moves.
+func levelEvals(evals []evalPoint) bool {
+	if gDrawOffers <= 0 || len(evals) < gDrawOffers {
+		return false
+	}
+	for _, e := range evals[len(evals)-gDrawOffers:] {
+		if abs(e.score) > gLevelScore {
+			return false
+		}
+	}
+	return true
+}
+
+// Engine moves to the mate it found against the human on its last move, 0 if
+// none. The engine's search was a ply before the human to move now.
+func mateAgainst(info *uciInfo, human chess.Color) int {
+	if info == nil || !info.Mate {
+		return 0
+	}
+	moves := info.Score // White's side.
+	if human == chess.White {
+		moves = -moves
+	}
+	if moves <= 1 { // The mate is the human's, or the game is over already.
+		return 0
+	}
+	return moves - 1
+}
+
+// Check whether the engine's evaluation stayed `--auto-resign` centipawns or
+// more against the human over its last `--auto-resign-moves` moves.
+func hopelessEvals(evals []evalPoint, human chess.Color) bool {
+	if gAutoResign <= 0 || len(evals) < gAutoResignMoves {
+		return false
+	}
+	for _, e := range evals[len(evals)-gAutoResignMoves:] {
+		score := e.score // White's side.
+		if human == chess.Black {
+			score = -score
+		}
+		if score > -gAutoResign {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/spf13/cobra"
+)
+
+// Endgame named by the pieces of both sides, kings first, "KRvK" or "KQvKR".
+var gEndgameRegex = regexp.MustCompile(`^K([QRBNP]*)vK([QRBNP]*)$`)
+
+// Tries at a random position before giving up on the endgame.
+const gEndgameTries = 1000
+
+// endgameCmd drops into a game from a random position of the endgame.
+var endgameCmd = &cobra.Command{
+	Use:   "endgame TYPE",
+	Short: "Practice converting a basic endgame against the engine",
+	Long: `Practice an endgame from a random position, e.g. KQvK, KRvK, KPvK, KBNvK or
+KQvKR. You play the first side, White unless --black is given, and move first.
+The position is legal and neither mate nor drawn yet, the rest is up to you.`,
+	Example: `  pinata endgame KRvK
+  pinata endgame KPvK --black --seed 7`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		m := gEndgameRegex.FindStringSubmatch(strings.Replace(strings.ToUpper(args[0]), "V", "v", 1))
+		if m == nil {
+			fmt.Println("Invalid endgame " + gConsole.Bold(gConsole.Red(args[0])).String() + ". Name the pieces of both sides, e.g. KRvK or KQvKR.")
+			os.Exit(1)
+		}
+		fen, err := endgamePosition(newRand(), m[1], m[2], humanColor())
+		if err != nil {
+			fmt.Println("Unable to set up", args[0]+",", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("Convert the", gConsole.Bold(gConsole.Yellow(m[0])).String(), "ending.")
+		gStartFEN = fen
+		shell()
+		onStop()
+	},
+}
+
+// FEN of a random position with the pieces of the stronger side for the
+// color, which is to move. The position is legal, the side not to move is not
+// in check and the game is neither over nor a dead draw.
+func endgamePosition(rnd *rand.Rand, strong, weak string, color chess.Color) (string, error) {
+	pieces := map[chess.Color]string{color: "K" + strong, color.Other(): "K" + weak}
+	for try := 0; try < gEndgameTries; try++ {
+		squares := map[chess.Square]chess.Piece{}
+		for _, side := range []chess.Color{chess.White, chess.Black} {
+			for i := range pieces[side] {
+				piece := gPieceLetters[pieces[side][i]][0]
+				if side == chess.Black {
+					piece = gPieceLetters[pieces[side][i]][1]
+				}
+				for {
+					sq := chess.Square(rnd.Intn(64))
+					if _, taken := squares[sq]; taken {
+						continue
+					}
+					if piece.Type() == chess.Pawn && (sq.Rank() == chess.Rank1 || sq.Rank() == chess.Rank8) {
+						continue
+					}
+					squares[sq] = piece
+					break
+				}
+			}
+		}
+		if fen, ok := playableEndgame(squares, color); ok {
+			return fen, nil
+		}
+	}
+	return "", fmt.Errorf("no playable position found")
+}
+
+// FEN of the placement with the color to move, if the game can go on from it.
+func playableEndgame(squares map[chess.Square]chess.Piece, color chess.Color) (string, bool) {
+	for sq, piece := range squares { // Kings apart, the side not to move out of check.
+		if piece.Type() == chess.King && piece.Color() != color && len(attackersOf(squares, sq, color)) > 0 {
+			return "", false
+		}
+	}
+
+	var placement strings.Builder
+	for rank := 7; rank >= 0; rank-- {
+		empty := 0
+		for file := 0; file < 8; file++ {
+			piece, ok := squares[chess.Square(rank*8+file)]
+			if !ok {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				fmt.Fprint(&placement, empty)
+				empty = 0
+			}
+			letter := strings.ToUpper(piece.Type().String())
+			if piece.Type() == chess.Pawn {
+				letter = "P"
+			}
+			if piece.Color() == chess.Black {
+				letter = strings.ToLower(letter)
+			}
+			placement.WriteString(letter)
+		}
+		if empty > 0 {
+			fmt.Fprint(&placement, empty)
+		}
+		if rank > 0 {
+			placement.WriteString("/")
+		}
+	}
+
+	turn := "w"
+	if color == chess.Black {
+		turn = "b"
+	}
+	fen := placement.String() + " " + turn + " - - 0 1"
+	opt, err := chess.FEN(fen)
+	if err != nil {
+		return "", false
+	}
+	game := chess.NewGame(opt)
+	if game.Position().Status() != chess.NoMethod || drawnMaterial(game.Position().Board()) {
+		return "", false
+	}
+	return fen, true
+}
+
+func init() {
+	rootCmd.AddCommand(endgameCmd)
+}
@@ -17,19 +17,105 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/abperiasamy/chess"
-	"github.com/freeeve/uci"
+	"github.com/chzyer/readline"
 )
 
+// Locate the engine executable, alternatively under the games dir.
+func findEngine(name string) (string, error) {
+	switch { // Built-in, see `--engine random` and `--engine builtin`.
+	case isRandomEngine(name):
+		return gRandomEngine, nil
+	case isBuiltinEngine(name):
+		return gBuiltinEngine, nil
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		path, err = exec.LookPath("/usr/games/" + name)
+	}
+	return path, err
+}
+
+// UCI engines looked for when none is configured, strongest first.
+var gKnownEngines = []string{
+	"stockfish", "lc0", "berserk", "ethereal", "rubichess", "koivisto", "igel", "laser", "fairy-stockfish", "toga2", "fruit",
+}
+
+// Find the UCI engines installed, to choose from when none is configured.
+// With more than one the player picks, the strongest by default. The choice
+// can be kept in the config file, see `--no-save-config`. Returns "" if no
+// engine is installed.
+func detectEngine() string {
+	var found []string
+	for _, name := range gKnownEngines {
+		if path, err := findEngine(name); err == nil {
+			found = append(found, path)
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	interactive := readline.IsTerminal(int(os.Stdin.Fd()))
+	in := bufio.NewReader(os.Stdin)
+	if len(found) == 1 || !interactive { // Scripted input is the game's.
+		fmt.Println("Playing against", gConsole.Bold(gConsole.Yellow(found[0])).String()+".")
+		if interactive {
+			offerSaveEngine(in, found[0])
+		}
+		return found[0]
+	}
+
+	fmt.Println("Engines found:")
+	for i, path := range found {
+		fmt.Printf("  %d. %s\n", i+1, path)
+	}
+	fmt.Print("Play against [1]: ")
+	choice := found[0]
+	answer, _ := in.ReadString('\n')
+	if n, err := strconv.Atoi(strings.TrimSpace(answer)); err == nil && n >= 1 && n <= len(found) {
+		choice = found[n-1]
+	}
+	offerSaveEngine(in, choice)
+	return choice
+}
+
+// Offer to keep the detected engine in the config file.
+func offerSaveEngine(in *bufio.Reader, path string) {
+	if gNoSaveConfig {
+		return
+	}
+	fmt.Print("Save it to ", gCfgFile, " for next time? [y/N] ")
+	answer, _ := in.ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return
+	}
+	if err := appendConfig("engine", path); err != nil {
+		fmt.Println("Unable to save the config file", gCfgFile+":", err)
+		return
+	}
+	fmt.Println("Saved to", gCfgFile+".")
+}
+
 // The shell initializes the engine upon entry.
-func newEngine(enginePath string) (*uci.Engine, error) {
+func newEngine(enginePath string) (*uciEngine, error) {
+	if _, err := findEngine(gEngineBinary); err != nil && !gEngineConfigured {
+		if path := detectEngine(); path != "" {
+			gEngineBinary = path
+		}
+	}
+
 	_, err := exec.LookPath(gEngineBinary)
 	if err != nil { // Alternatively look under games dir.
-		path, err := exec.LookPath("/usr/games/" + gEngineBinary)
+		path, err := findEngine(gEngineBinary)
 		if err != nil {
 			fmt.Println("Unable to find " + gConsole.Bold(gConsole.Red(gEngineBinary)).String() + ". Please use `--engine` flag to choose a UCI compatible engine.")
 			os.Exit(1)
@@ -37,74 +123,369 @@ func newEngine(enginePath string) (*uci.Engine, error) {
 		gEngineBinary = path
 	}
 
-	eng, err := uci.NewEngine(enginePath)
+	eng, err := newUCIEngine(gEngineBinary)
 	if err != nil {
 		fmt.Println(gConsole.Red(err))
 		fmt.Println("Unable to initialize " + gConsole.Bold(gConsole.Red(gEngineBinary)).String() + ". Please use `--engine` flag to choose a UCI compatible engine.")
 		os.Exit(1)
 	}
+	// The default threads quietly fit the engine, threads asked for are held to its range.
+	threads := gThreads
+	if opt, ok := eng.Options["threads"]; ok && !gThreadsConfigured && opt.Type == "spin" && threads > opt.Max && opt.Max >= opt.Min {
+		threads = opt.Max
+	}
+	setResources(eng, threads)
+	setProfileOptions(eng)
 
 	return eng, err
 }
 
-// Engine's first move as white
-func engineMoveFirst(engine *uci.Engine, game *chess.Game) error {
-	results, err := engine.GoDepth(gEngineDepth, uci.HighestDepthOnly)
+const gDefaultThreads = 8
+
+// Give the engine the `--hash` and the threads, held within its advertised
+// range. Engines without threads search on one.
+func setResources(eng *uciEngine, threads int) {
+	if opt, ok := eng.Options["threads"]; ok {
+		eng.SendOption(opt.Name, clampOption(opt, threads, "--threads"))
+	}
+	if gHash == 0 {
+		return
+	}
+	opt, ok := eng.Options["hash"]
+	if !ok {
+		fmt.Println(gConsole.Bold(gConsole.Red(gEngineBinary)), "has no hash table to size, --hash is ignored.")
+		return
+	}
+	eng.SendOption(opt.Name, clampOption(opt, gHash, "--hash"))
+}
+
+// The value within the spin option's range, telling when it is not.
+func clampOption(opt uciOption, value int, flag string) int {
+	if opt.Type != "spin" || opt.Max < opt.Min {
+		return value
+	}
+	switch {
+	case value > opt.Max:
+		fmt.Println(flag, value, "exceeds the engine's maximum, using", strconv.Itoa(opt.Max)+".")
+		return opt.Max
+	case value < opt.Min:
+		fmt.Println(flag, value, "is below the engine's minimum, using", strconv.Itoa(opt.Min)+".")
+		return opt.Min
+	}
+	return value
+}
+
+// Set the engine's contempt from `--contempt` and tell what it does. Positive
+// contempt avoids draws, negative contempt seeks them.
+func setContempt(eng *uciEngine) {
+	if gContempt == "" {
+		return
+	}
+	opt, ok := eng.Options["contempt"]
+	if !ok {
+		fmt.Println(gConsole.Bold(gConsole.Red(gEngineBinary)), "does not support contempt, --contempt is ignored.")
+		return
+	}
+	cp, _ := strconv.Atoi(gContempt) // Checked by initGlobals.
+	if opt.Type == "spin" && (cp < opt.Min || cp > opt.Max) {
+		fmt.Println("Contempt", cp, "is out of the engine's range", opt.Min, "to", strconv.Itoa(opt.Max)+", --contempt is ignored.")
+		return
+	}
+	eng.SendOption(opt.Name, cp)
+
+	switch {
+	case cp > 0:
+		fmt.Printf("Contempt %+d: the engine avoids draws and plays on in level positions.\n", cp)
+	case cp < 0:
+		fmt.Printf("Contempt %+d: the engine seeks draws and settles for level positions.\n", cp)
+	default:
+		fmt.Println("Contempt 0: the engine takes a draw for what it is worth.")
+	}
+}
+
+// Ask the engine for its best move in the game's position, none if the game is over.
+func searchMove(engine *uciEngine, game *chess.Game, params string) (*chess.Move, *uciResults, error) {
+	engine.SetFEN(game.FEN())
+	results, err := engine.Go(params)
 	if err != nil {
-		fmt.Println(err)
-		return err
+		return nil, nil, err
 	}
 
-	moveLAN, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), results.BestMove)
+	if results.NoMove() { // The game is over, no move to play.
+		if len(game.ValidMoves()) > 0 {
+			return nil, nil, fmt.Errorf("engine found no move in a position with legal moves")
+		}
+		return nil, results, nil
+	}
+	move, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), results.BestMove)
 	if err != nil {
-		fmt.Println(err)
+		return nil, nil, err
+	}
+	if gEngineNodes > 0 && strings.Contains(params, "nodes ") {
+		checkNodesLimit(engine, results)
+	}
+	return move, results, nil
+}
+
+// Engine's evaluation of the best line from White's side, nil if not reported.
+func whiteInfo(results *uciResults, color chess.Color) *uciInfo {
+	info, ok := results.Best()
+	if !ok {
+		return nil
+	}
+	if color == chess.Black { // Engines score from the side to move.
+		info.Score = -info.Score
+	}
+	return &info
+}
+
+// SAN of the engine's move in the current position.
+func moveSAN(game *chess.Game, moveLAN *chess.Move) string {
+	// Only the valid moves list has the equivalent SAN move with tag pairs.
+	for _, move := range game.Position().ValidMoves() {
+		if moveLAN.String() == chess.Encoder.Encode(chess.LongAlgebraicNotation{}, game.Position(), move) {
+			return encodeSAN(game.Position(), move)
+		}
+	}
+	return moveLAN.String()
+}
+
+// The move as shown to the player, SAN or with `--dual-notation` also the
+// coordinates, "Nf3 (g1f3)".
+func showMove(game *chess.Game, move *chess.Move) string {
+	san := markCheck(moveSAN(game, move))
+	if !gDualNotation {
+		return san
+	}
+	return san + " (" + move.String() + ")"
+}
+
+// Show the engine's search progress in place on the current line, if asked for.
+// Call the returned function once the search is over.
+func showThinking(engine *uciEngine, game *chess.Game) (done func()) {
+	if !gThinking || gPremove || !isTerminal() { // The premove input owns the line.
+		return func() {}
+	}
+
+	robot := "🤖"
+	if gNoColor {
+		robot = ":]"
+	}
+	engine.OnInfo = func(info uciInfo) {
+		if info.MultiPV > 1 || len(info.PV) == 0 { // Only the best line.
+			return
+		}
+		move, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), info.PV[0])
+		if err != nil {
+			return
+		}
+		fmt.Printf("\r\033[K%s %s %s %s", robot, gConsole.Faint(fmt.Sprintf("depth %d", info.Depth)),
+			gConsole.Bold(gConsole.Yellow(moveSAN(game, move))), formatEval(game, info))
+	}
+	return func() {
+		engine.OnInfo = nil
+		fmt.Print("\r\033[K") // Clear the progress line.
+	}
+}
+
+// Search limits for the engine's next move.
+func engineGoParams() string {
+	params := searchLimits()
+	if gClock == nil {
+		if params == "" {
+			return "depth " + strconv.Itoa(gEngineDepth)
+		}
+		return params
+	}
+
+	// Explicit depth or nodes cap the timed search.
+	return strings.TrimSpace(params + " " + gClock.GoParams(tcMovesToGo()))
+}
+
+// Depth and nodes limits of the search, "" if neither is set.
+func searchLimits() string {
+	var limits []string
+	if gEngineDepth > 0 {
+		limits = append(limits, "depth "+strconv.Itoa(gEngineDepth))
+	}
+	if gEngineNodes > 0 {
+		limits = append(limits, "nodes "+strconv.Itoa(gEngineNodes))
+	}
+	return strings.Join(limits, " ")
+}
+
+// The nodes limit is not advertised by engines, an engine searching far past
+// it is taken to ignore it. Warned once.
+var gNodesIgnored sync.Once
+
+func checkNodesLimit(engine *uciEngine, results *uciResults) {
+	info, ok := results.Best()
+	if !ok || info.Nodes <= 2*gEngineNodes { // Engines check the limit every so often.
+		return
+	}
+	gNodesIgnored.Do(func() {
+		fmt.Println(gConsole.Bold(gConsole.Red(engine.Name)), "searched", info.Nodes, "nodes, it does not seem to support the --nodes limit.")
+	})
+}
+
+// Engine's move in the current position
+func engineMove(engine *uciEngine, game *chess.Game) error {
+	moveLAN, err := engineReply(engine, game)
+	if err != nil || moveLAN == nil {
 		return err
 	}
 
-	err = game.Move(moveLAN)
+	fmt.Println(enginePrompt() + showMove(game, moveLAN))
+	fmt.Print(gSearchNote)
+
+	err = applyMove(game, moveLAN)
 	if err != nil {
 		fmt.Println(err)
 		return err
 	}
+	mirrorFEN(game)
+	gSessionLog.moves(game)
 
-	fmt.Println(enginePrompt() + chess.Encoder.Encode(chess.AlgebraicNotation{}, game.Position(), moveLAN))
 	drawBoard(game)
 	return nil
 }
 
-// Send human move to engine and get a counter move in response
-func engineMoveNext(engine *uci.Engine, game *chess.Game, moveStr string) error {
-	err := game.MoveStr(moveStr)
-	if err != nil {
-		fmt.Println("Allowed moves:", gConsole.Bold(gConsole.Yellow(validMoves(game))))
-		return err
+// The repertoire reply or else the engine's search. With `--min-reply-time`
+// the reply waits out the rest of that time, off the engine's clock.
+func engineReply(engine *uciEngine, game *chess.Game) (*chess.Move, error) {
+	gSearchNote = ""
+	start := time.Now()
+	if gRepertoire != nil { // Repertoire replies are played right away.
+		if move := gRepertoire.reply(game); move != nil {
+			time.Sleep(gMinReplyTime - time.Since(start))
+			return move, nil
+		}
 	}
-	engine.SetFEN(game.FEN())
-	results, err := engine.GoDepth(gEngineDepth, uci.HighestDepthOnly)
-	if err != nil {
-		fmt.Println(err)
-		return err
+	move, err := engineSearch(engine, game)
+	if move != nil {
+		gSearchNote += explainMove(game, move) + moveDelta(game, move)
+		time.Sleep(gMinReplyTime - time.Since(start))
 	}
+	return move, err
+}
 
-	moveLAN, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), results.BestMove)
+// Search the engine's move on its clock. No move if the engine ran out of time.
+func engineSearch(engine *uciEngine, game *chess.Game) (*chess.Move, error) {
+	color := game.Position().Turn()
+	if gClock != nil {
+		gClock.Start(color)
+	}
+	done := showThinking(engine, game)
+	single := varietyLines(engine)
+	moveLAN, results, err := searchMove(engine, game, engineGoParams())
+	single()
+	done()
 	if err != nil {
 		fmt.Println(err)
-		return err
+		return nil, err
+	}
+	if gClock != nil {
+		if gClock.Flagged(color) {
+			flagFall(game, color)
+			return nil, nil
+		}
+		gClock.Stop()
 	}
+	gLastInfo = whiteInfo(results, color)
+	recordEval(game, gLastInfo)
+	gSearchNote = explainSearch(game, results)
+	return varietyMove(game, results, moveLAN), nil
+}
 
-	// Only the valid moves list has the equivalent SAN move with tag pairs.
-	for _, move := range game.Position().ValidMoves() {
-		if moveLAN.String() == chess.Encoder.Encode(chess.LongAlgebraicNotation{}, game.Position(), move) {
-			fmt.Println(enginePrompt() + chess.Encoder.Encode(chess.AlgebraicNotation{}, game.Position(), move))
+// Explain the engine's search with `--verbose`: depth, nodes, time, evaluation
+// and the line it chose. "" when not asked for or not reported.
+func explainSearch(game *chess.Game, results *uciResults) string {
+	info, ok := results.Best()
+	if !gVerbose || !ok {
+		return ""
+	}
+	line := fmt.Sprintf("depth %d/%d, %s nodes in %.2fs", info.Depth, info.SelDepth, formatNodes(info.Nodes), float64(info.Time)/1000)
+	line += ", eval " + formatEval(game, info)
+	if pv := pvSAN(game, info.PV); len(pv) > 0 {
+		line += ": " + strings.Join(pv, " ")
+	}
+	return gConsole.Faint(line).String() + "\n"
+}
+
+// The first moves of the engine's line in SAN, enough to see the idea.
+func pvSAN(game *chess.Game, line []string) []string {
+	var pv []string
+	game = game.Clone()
+	for i, lan := range line {
+		if i == 8 {
+			pv = append(pv, "…")
+			break
 		}
+		move, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), lan)
+		if err != nil {
+			break
+		}
+		pv = append(pv, moveSAN(game, move))
+		if game.Move(move) != nil {
+			break
+		}
+	}
+	return pv
+}
+
+// Node count in short, 950, 12.3k or 4.5M.
+func formatNodes(nodes int) string {
+	switch {
+	case nodes >= 1000000:
+		return fmt.Sprintf("%.1fM", float64(nodes)/1000000)
+	case nodes >= 1000:
+		return fmt.Sprintf("%.1fk", float64(nodes)/1000)
+	}
+	return strconv.Itoa(nodes)
+}
+
+// Play the move of either player in the two-player mode
+func humanMove(game *chess.Game, moveStr string) error {
+	if err := playHumanMove(game, moveStr); err != nil {
+		return err
 	}
+	gHumanIsBlack = game.Position().Turn() == chess.Black // Over to the other player.
+	drawBoard(game)
+	return nil
+}
 
-	err = game.Move(moveLAN)
+// Play the human's move on the human's clock.
+func playHumanMove(game *chess.Game, moveStr string) error {
+	if gClock != nil && gClock.Flagged(humanColor()) {
+		flagFall(game, humanColor())
+		return nil
+	}
+
+	move, err := decodeMove(game, moveStr)
 	if err != nil {
+		printMoveError(game, err)
+		return err
+	}
+	if gDualNotation { // Echo the move as typed in both notations.
+		fmt.Println(gConsole.Faint(showMove(game, move)))
+	}
+	reportOnlyMove(game, move)
+	if err = applyMove(game, move); err != nil {
 		fmt.Println(err)
 		return err
 	}
-
-	drawBoard(game)
+	mirrorFEN(game)
+	gSessionLog.moves(game)
+	if gClock != nil {
+		gClock.Stop()
+	}
 	return nil
 }
+
+// Send human move to engine and get a counter move in response
+func engineMoveNext(engine *uciEngine, game *chess.Game, moveStr string) error {
+	if err := playHumanMove(game, moveStr); err != nil || game.Outcome() != chess.NoOutcome {
+		return err
+	}
+	return engineMove(engine, game)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const gEngineLogMaxSize = 10 << 20 // Rotate the engine log beyond 10 MiB.
+
+// Log of the UCI conversation with all the engines, see `--log-engine`.
+type engineLog struct {
+	mu   sync.Mutex // Matches talk to several engines at once.
+	file *os.File
+}
+
+// Open the log for appending. A log grown too large is kept as "<path>.1"
+// and a fresh one started.
+func openEngineLog(path string) (*engineLog, error) {
+	if fInfo, err := os.Stat(path); err == nil && fInfo.Size() > gEngineLogMaxSize {
+		if err = os.Rename(path, path+".1"); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &engineLog{file: file}, nil
+}
+
+// Record a line sent to (">") or received from ("<") the engine process.
+func (l *engineLog) record(pid int, direction, line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.file, "%s [%d] %s %s\n", time.Now().Format("2006-01-02 15:04:05.000"), pid, direction, line)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// The engine's evaluations of a saved game, kept next to the PGN so that
+// reopening the game shows the eval graph without the engine. The moves tell
+// whether the evaluations still belong to the game in the PGN.
+type evalCache struct {
+	Moves []string    `json:"moves"`
+	Evals []evalEntry `json:"evals"`
+}
+
+type evalEntry struct {
+	Move  int `json:"move"`          // Full move number.
+	Ply   int `json:"ply,omitempty"` // Moves played before the position.
+	Score int `json:"score"`         // Centipawns from White's side.
+}
+
+// The eval cache of a PGN file, "game.pgn" keeps them in "game.evals.json".
+func evalCachePath(filename string) string {
+	return strings.TrimSuffix(filename, ".pgn") + ".evals.json"
+}
+
+// The game's moves in coordinate notation.
+func uciMoves(game *chess.Game) []string {
+	moves := make([]string, len(game.Moves()))
+	for i, move := range game.Moves() {
+		moves[i] = move.String()
+	}
+	return moves
+}
+
+// Save the evaluations of the game saved to the PGN file, if any.
+func saveEvals(game *chess.Game, filename string, evals []evalPoint) {
+	if len(evals) == 0 {
+		return
+	}
+	cache := evalCache{Moves: uciMoves(game)}
+	for _, e := range evals {
+		cache.Evals = append(cache.Evals, evalEntry{Move: e.move, Ply: e.ply, Score: e.score})
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(evalCachePath(filename), string(data)+"\n")
+	}
+	if err != nil {
+		fmt.Println("Unable to save the evaluations to", gConsole.Bold(gConsole.Red(evalCachePath(filename))).String()+",", err)
+	}
+}
+
+// Load the evaluations saved with the PGN file. None if there are none, or the
+// game has changed since.
+func loadEvals(game *chess.Game, filename string) []evalPoint {
+	data, err := ioutil.ReadFile(evalCachePath(filename))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Println("Unable to read the evaluations,", err)
+		}
+		return nil
+	}
+	var cache evalCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		fmt.Println(gConsole.Bold(gConsole.Red(evalCachePath(filename))), "is not an eval cache, ignored.")
+		return nil
+	}
+	if strings.Join(cache.Moves, " ") != strings.Join(uciMoves(game), " ") { // Stale.
+		return nil
+	}
+
+	evals := make([]evalPoint, len(cache.Evals))
+	for i, e := range cache.Evals {
+		evals[i] = evalPoint{move: e.Move, ply: e.Ply, score: e.Score}
+	}
+	return evals
+}
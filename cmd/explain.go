@@ -0,0 +1,244 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// The engine's move in plain words for beginners, `--explain`. These are rough
+// guesses from the positions before and after the move, not the engine's
+// reasons, which are in its search.
+func explainMove(game *chess.Game, move *chess.Move) string {
+	if !gExplain {
+		return ""
+	}
+	pos := game.Position()
+	next := pos.Update(move)
+	board := pos.Board()
+	piece := board.Piece(move.S1())
+	color := piece.Color()
+	name := pieceTypeName(piece.Type())
+
+	var reasons []string
+	switch {
+	case move.HasTag(chess.KingSideCastle) || move.HasTag(chess.QueenSideCastle):
+		reasons = append(reasons, "castles the king to safety")
+	case move.Promo() != chess.NoPieceType:
+		reasons = append(reasons, "promotes to a "+pieceTypeName(move.Promo()))
+	}
+
+	if move.HasTag(chess.Capture) || move.HasTag(chess.EnPassant) {
+		captured := chess.Pawn
+		if !move.HasTag(chess.EnPassant) {
+			captured = board.Piece(move.S2()).Type()
+		}
+		switch {
+		case !attacked(next, move.S2(), color.Other()):
+			reasons = append(reasons, "wins a "+pieceTypeName(captured))
+		case gPieceValues[captured] == gPieceValues[piece.Type()]:
+			reasons = append(reasons, "trades "+name+"s")
+		default:
+			reasons = append(reasons, "takes a "+pieceTypeName(captured))
+		}
+	}
+
+	backRank := chess.Rank1
+	if color == chess.Black {
+		backRank = chess.Rank8
+	}
+	if (piece.Type() == chess.Knight || piece.Type() == chess.Bishop) && move.S1().Rank() == backRank {
+		reasons = append(reasons, "develops a "+name)
+	}
+	if piece.Type() == chess.Pawn {
+		switch move.S2() {
+		case chess.D4, chess.E4, chess.D5, chess.E5:
+			reasons = append(reasons, "claims the center")
+		}
+	}
+	if passed := newPassedPawn(pos, next, color); passed != "" {
+		reasons = append(reasons, "creates a passed pawn on "+passed)
+	}
+
+	switch {
+	case next.Status() == chess.Checkmate:
+		reasons = append(reasons, "delivers mate")
+	case move.HasTag(chess.Check):
+		reasons = append(reasons, "gives check")
+	default:
+		reasons = append(reasons, targets(next, move.S2(), color)...)
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, "a quiet move with the "+name)
+	}
+	if len(reasons) > 1 {
+		last := len(reasons) - 1
+		reasons = append(reasons[:last-1], reasons[last-1]+" and "+reasons[last])
+	}
+	return gConsole.Faint("Idea: "+strings.Join(reasons, ", ")+".").String() + "\n"
+}
+
+// How the human's last move went with `--move-delta`, once the engine has its
+// reply: the change of the engine's evaluation and of the material, counting
+// the reply, "Your move: -0.3 (traded a knight for a bishop)".
+func moveDelta(game *chess.Game, reply *chess.Move) string {
+	moves, positions := game.Moves(), game.Positions()
+	if !gMoveDelta || gTwoPlayer || len(moves) == 0 || positions[len(moves)-1].Turn() != humanColor() {
+		return ""
+	}
+	last, before := moves[len(moves)-1], positions[len(moves)-1]
+
+	var notes []string
+	if n := len(gEvals); n >= 2 {
+		delta := gEvals[n-1].score - gEvals[n-2].score // White's side.
+		if humanColor() == chess.Black {
+			delta = -delta
+		}
+		notes = append(notes, fmt.Sprintf("%+.1f", float64(delta)/100))
+	}
+
+	got, won := capturedType(before, last)
+	gave, lost := capturedType(game.Position(), reply)
+	material := ""
+	switch {
+	case won && lost && got == gave:
+		material = "traded " + pieceTypeName(got) + "s"
+	case won && lost:
+		material = "traded a " + pieceTypeName(gave) + " for a " + pieceTypeName(got)
+	case won:
+		material = "won a " + pieceTypeName(got)
+	case lost:
+		material = "lost a " + pieceTypeName(gave)
+	}
+	if last.Promo() != chess.NoPieceType {
+		material = strings.TrimPrefix(material+", promoted to a "+pieceTypeName(last.Promo()), ", ")
+	}
+	switch {
+	case material != "" && len(notes) > 0:
+		notes[0] += " (" + material + ")"
+	case material != "":
+		notes = append(notes, material)
+	}
+
+	if len(notes) == 0 {
+		return ""
+	}
+	return gConsole.Faint("Your move: "+notes[0]).String() + "\n"
+}
+
+// The type of the piece the move takes, if it takes one.
+func capturedType(pos *chess.Position, move *chess.Move) (chess.PieceType, bool) {
+	switch {
+	case move.HasTag(chess.EnPassant):
+		return chess.Pawn, true
+	case move.HasTag(chess.Capture):
+		return pos.Board().Piece(move.S2()).Type(), true
+	}
+	return chess.NoPieceType, false
+}
+
+// The position with the color to move, to see what its pieces attack.
+func withTurn(pos *chess.Position, color chess.Color) *chess.Position {
+	fields := strings.Fields(pos.String())
+	fields[1], fields[3] = "w", "-"
+	if color == chess.Black {
+		fields[1] = "b"
+	}
+	fen, err := chess.FEN(strings.Join(fields, " "))
+	if err != nil {
+		return nil
+	}
+	return chess.NewGame(fen).Position()
+}
+
+// Can a piece of the color capture on the square?
+func attacked(pos *chess.Position, sq chess.Square, color chess.Color) bool {
+	if pos = withTurn(pos, color); pos == nil {
+		return false
+	}
+	for _, m := range pos.ValidMoves() {
+		if m.S2() == sq {
+			return true
+		}
+	}
+	return false
+}
+
+// What the piece on the square goes after: enemy pieces worth more than
+// itself, and the weak f7 or f2 square next to a king still at home.
+func targets(pos *chess.Position, from chess.Square, color chess.Color) []string {
+	piece := pos.Board().Piece(from)
+	if pos = withTurn(pos, color); pos == nil {
+		return nil
+	}
+	weak, home := chess.F7, chess.E8
+	if color == chess.Black {
+		weak, home = chess.F2, chess.E1
+	}
+
+	var found []string
+	for _, m := range pos.ValidMoves() {
+		if m.S1() != from {
+			continue
+		}
+		target := pos.Board().Piece(m.S2())
+		switch {
+		case m.S2() == weak && pos.Board().Piece(home).Type() == chess.King:
+			found = append(found, "eyes "+weak.String())
+		case m.HasTag(chess.Capture) && target.Type() != chess.King && gPieceValues[target.Type()] > gPieceValues[piece.Type()]:
+			found = append(found, "attacks the "+pieceTypeName(target.Type())+" on "+m.S2().String())
+		}
+	}
+	return found
+}
+
+// The square of a passed pawn of the color the move created, if any.
+func newPassedPawn(before, after *chess.Position, color chess.Color) string {
+	passed := func(pos *chess.Position) map[chess.Square]bool {
+		squares := pos.Board().SquareMap()
+		pawns := map[chess.Color][8][]int{}
+		for sq, p := range squares {
+			if p.Type() == chess.Pawn {
+				files := pawns[p.Color()]
+				files[sq.File()] = append(files[sq.File()], int(sq.Rank()))
+				pawns[p.Color()] = files
+			}
+		}
+		found := map[chess.Square]bool{}
+		for sq, p := range squares {
+			if p.Type() == chess.Pawn && p.Color() == color && isPassed(pawns[color.Other()], int(sq.File()), int(sq.Rank()), color) {
+				found[sq] = true
+			}
+		}
+		return found
+	}
+
+	was, is := passed(before), passed(after)
+	if len(is) <= len(was) { // A passer pushed on is no news.
+		return ""
+	}
+	for sq := range is {
+		if !was[sq] {
+			return sq.String()
+		}
+	}
+	return ""
+}
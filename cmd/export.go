@@ -0,0 +1,320 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gExportFormat   string
+	gExportOutput   string
+	gExportNotation string
+	gExportAll      bool
+	gExportDir      string
+)
+
+// Engine's evaluation after a move, as exported to JSON.
+type moveEval struct {
+	Ply   int    `json:"ply"`
+	Move  string `json:"move"`
+	FEN   string `json:"fen"`
+	Score int    `json:"score"` // Centipawns from White's side, or moves to mate.
+	Mate  bool   `json:"mate"`
+}
+
+// exportCmd writes a saved game in other formats.
+var exportCmd = &cobra.Command{
+	Use:   "export FILE",
+	Short: "Export a saved game as an image, evaluations or a zip bundle of all",
+	Long: `Export a saved game. The formats are:
+  pgn   the game itself
+  svg   the final position
+  json  the engine's evaluation after every move
+  zip   all of the above in a single archive
+  movetext  just the moves on a line, printed unless --output is given
+
+With --all, FILE is a PGN database to write with all the games saved in --dir,
+oldest first.`,
+	Example: `  pinata export pinata.pgn --format zip -o club-night.zip
+  pinata export --all games.pgn --dir ~/chess`,
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		filename := args[0]
+		if gExportAll {
+			if dryRun(filename) {
+				return
+			}
+			count, err := exportAll(gExportDir, filename)
+			if err != nil {
+				fmt.Println("Unable to export the games to", gConsole.Bold(gConsole.Red(filename)).String()+",", err)
+				os.Exit(1)
+			}
+			fmt.Println(count, "games exported to", gConsole.Bold(gConsole.Red(filename)))
+			return
+		}
+		switch gExportFormat {
+		case "pgn", "svg", "json", "zip", "movetext":
+		default:
+			fmt.Println("Invalid --format value " + strconv.Quote(gExportFormat) + ". Allowed values are [pgn|svg|json|zip|movetext].")
+			os.Exit(1)
+		}
+		switch gExportNotation {
+		case "san", "lan", "uci":
+		default:
+			fmt.Println("Invalid --notation value " + strconv.Quote(gExportNotation) + ". Allowed values are [san|lan|uci].")
+			os.Exit(1)
+		}
+
+		game := readPGN(nil, filename)
+		if game == nil {
+			os.Exit(1)
+		}
+
+		if gExportFormat == "movetext" && gExportOutput == "" { // For pasting.
+			fmt.Println(movetext(game, gExportNotation))
+			return
+		}
+
+		output := gExportOutput
+		if output == "" { // Next to the game.
+			output = strings.TrimSuffix(filename, filepath.Ext(filename)) + "." + gExportFormat
+		}
+
+		if dryRun(output) {
+			return
+		}
+		var err error
+		switch gExportFormat {
+		case "pgn":
+			err = ioutil.WriteFile(output, []byte(pgnText(game)+"\n"), 0644)
+		case "svg":
+			err = ioutil.WriteFile(output, []byte(boardSVG(game.Position().Board())), 0644)
+		case "json":
+			var evals []byte
+			if evals, err = evalsJSON(game); err == nil {
+				err = ioutil.WriteFile(output, evals, 0644)
+			}
+		case "movetext":
+			err = ioutil.WriteFile(output, []byte(movetext(game, gExportNotation)+"\n"), 0644)
+		case "zip":
+			err = exportZip(game, filepath.Base(strings.TrimSuffix(filename, filepath.Ext(filename))), output)
+		}
+		if err != nil {
+			fmt.Println("Unable to export the game to", gConsole.Bold(gConsole.Red(output)).String()+",", err)
+			os.Exit(1)
+		}
+		fmt.Println("Game exported to", gConsole.Bold(gConsole.Red(output)))
+	},
+}
+
+// Write the games saved in the directory to a single PGN database, sorted by
+// date. Files that do not hold a valid game are left out with a notice.
+// Returns the number of games exported.
+func exportAll(dir, output string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pgn"))
+	if err != nil {
+		return 0, err
+	}
+	outputPath, _ := filepath.Abs(output)
+
+	type savedGame struct {
+		date string
+		text string
+	}
+	var games []savedGame
+	for _, file := range files {
+		if path, _ := filepath.Abs(file); path == outputPath { // Not the database itself.
+			continue
+		}
+		game := readPGN(nil, file)
+		if game == nil {
+			fmt.Println("Left out", gConsole.Bold(gConsole.Red(file)).String()+".")
+			continue
+		}
+		games = append(games, savedGame{date: GetTagPair(game, "Date"), text: pgnText(game)})
+	}
+	if len(games) == 0 {
+		return 0, fmt.Errorf("no saved games in %s", dir)
+	}
+	sort.SliceStable(games, func(i, j int) bool { return games[i].date < games[j].date })
+
+	// A blank line between the movetext of a game and the tags of the next.
+	texts := make([]string, len(games))
+	for i, g := range games {
+		texts[i] = g.text
+	}
+	return len(games), writeFileAtomic(output, strings.Join(texts, "\n\n")+"\n")
+}
+
+// The moves alone, "1. e4 e5 2. Nf3", in SAN, LAN (e2-e4) or UCI (e2e4) notation.
+func movetext(game *chess.Game, notation string) string {
+	var moves []string
+	positions := game.Positions()
+	for i, move := range game.Moves() {
+		pos := positions[i]
+		text := encodeSAN(pos, move)
+		switch notation {
+		case "lan":
+			text = moveLAN(pos, positions[i+1], move)
+		case "uci":
+			text = move.String()
+		}
+		if pos.Turn() == chess.White || i == 0 { // Also when Black moves first.
+			text = moveNumber(pos) + text
+		}
+		moves = append(moves, text)
+	}
+	return strings.Join(moves, " ")
+}
+
+// Long algebraic notation of the move, "Ng1-f3", "e4xd5" or "e7-e8=Q+".
+func moveLAN(pos, next *chess.Position, move *chess.Move) string {
+	var lan string
+	switch {
+	case move.HasTag(chess.KingSideCastle):
+		lan = "O-O"
+	case move.HasTag(chess.QueenSideCastle):
+		lan = "O-O-O"
+	default:
+		lan = strings.ToUpper(pos.Board().Piece(move.S1()).Type().String()) + move.S1().String()
+		if move.HasTag(chess.Capture) || move.HasTag(chess.EnPassant) {
+			lan += "x"
+		} else {
+			lan += "-"
+		}
+		lan += move.S2().String()
+		if move.Promo() != chess.NoPieceType {
+			lan += "=" + strings.ToUpper(move.Promo().String())
+		}
+	}
+	if next.Status() == chess.Checkmate {
+		return lan + "#"
+	}
+	if move.HasTag(chess.Check) {
+		return lan + "+"
+	}
+	return lan
+}
+
+// Ask the engine to evaluate the position after every move.
+func evaluateGame(game *chess.Game) ([]moveEval, error) {
+	engine, _ := newEngine(gEngineBinary)
+	defer engine.Close()
+	if err := engine.IsReady(); err != nil {
+		return nil, err
+	}
+
+	depth := gEngineDepth
+	if depth < 1 {
+		depth = 10
+	}
+	params := "depth " + strconv.Itoa(depth)
+
+	positions, moves := game.Positions(), game.Moves()
+	evals := make([]moveEval, 0, len(moves))
+	for i, move := range moves {
+		pos := positions[i+1]
+		eval := moveEval{Ply: i + 1, Move: encodeSAN(positions[i], move), FEN: pos.String()}
+
+		if pos.Status() == chess.NoMethod { // Nothing to evaluate after mate or stalemate.
+			engine.SetFEN(pos.String())
+			results, err := engine.Go(params)
+			if err != nil {
+				return nil, err
+			}
+			if info := whiteInfo(results, pos.Turn()); info != nil {
+				eval.Score, eval.Mate = info.Score, info.Mate
+			}
+		} else if pos.Status() == chess.Checkmate {
+			eval.Mate = true
+		}
+		evals = append(evals, eval)
+		fmt.Printf("\rEvaluated %d/%d moves", i+1, len(moves))
+	}
+	fmt.Println()
+	return evals, nil
+}
+
+// Per-move evaluations as indented JSON.
+func evalsJSON(game *chess.Game) ([]byte, error) {
+	evals, err := evaluateGame(game)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(evals, "", "  ")
+}
+
+// Bundle the game, its final position and evaluations in a zip archive.
+func exportZip(game *chess.Game, name, filename string) error {
+	evals, err := evalsJSON(game)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	entries := []struct {
+		name string
+		data string
+	}{
+		{name + ".pgn", pgnText(game) + "\n"},
+		{name + ".svg", boardSVG(game.Position().Board())},
+		{name + ".json", string(evals) + "\n"},
+	}
+	for _, entry := range entries {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write([]byte(entry.data)); err != nil {
+			return err
+		}
+	}
+	if err = archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&gExportFormat, "format", "zip", "export format [pgn|svg|json|zip|movetext]")
+	exportCmd.Flags().StringVar(&gExportNotation, "notation", "san", "move notation of the movetext [san|lan|uci]")
+	exportCmd.Flags().StringVarP(&gExportOutput, "output", "o", "", "output file (defaults to the game's name with the format's extension)")
+	exportCmd.Flags().BoolVar(&gExportAll, "all", false, "write all the saved games to FILE as a single PGN database")
+	exportCmd.Flags().StringVar(&gExportDir, "dir", ".", "directory of the saved games to export with --all")
+	rootCmd.AddCommand(exportCmd)
+}
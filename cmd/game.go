@@ -17,15 +17,23 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/abperiasamy/chess"
+	"github.com/chzyer/readline"
 )
 
+// Standard starting position, games from other positions carry it in the FEN tag.
+const gStandardFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
 // Lookup tag pair
 func GetTagPair(game *chess.Game, key string) string {
 	if game != nil { // Success
@@ -37,15 +45,101 @@ func GetTagPair(game *chess.Game, key string) string {
 	return ""
 }
 
-// Start a game from a PGN file
-func loadPGN(filename string) *chess.Game {
-	pgnDat, err := ioutil.ReadFile(filename)
+// Variant tag of a PGN file, e.g. [Variant "Chess960"].
+var gVariantRegex = regexp.MustCompile(`\[Variant\s+"([^"]*)"\]`)
+
+// Tag pair of the PGN text, for the games of a database before parsing them.
+var gTagPairRegex = regexp.MustCompile(`(?m)^\[(\w+)\s+"((?:[^"\\]|\\.)*)"\]`)
+
+// A tag value's backslashes and quotes are escaped in PGN, `"O\"Kelly"`.
+var gTagEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+var gTagUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+
+// The games of a PGN file, a database holds many. "-" reads the standard input.
+func pgnGames(filename string) ([]string, error) {
+	var pgnDat []byte
+	var err error
+	if filename == "-" {
+		pgnDat, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		pgnDat, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A game starts with its tags, after the movetext of the one before.
+	var games []string
+	var game strings.Builder
+	inMoves := false
+	for _, line := range strings.SplitAfter(string(pgnDat), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && inMoves {
+			games = append(games, game.String())
+			game.Reset()
+			inMoves = false
+		} else if trimmed != "" && !strings.HasPrefix(trimmed, "[") {
+			inMoves = true
+		}
+		game.WriteString(line)
+	}
+	if strings.TrimSpace(game.String()) != "" || len(games) == 0 {
+		games = append(games, game.String())
+	}
+	return games, nil
+}
+
+// Pick a game of a database, listed by players, result and date. The first
+// without a terminal to ask on.
+func chooseGame(l *readline.Instance, filename string, games []string) int {
+	fmt.Println(gConsole.Bold(gConsole.Yellow(filename)).String(), "holds", len(games), "games:")
+	for i, game := range games {
+		tags := map[string]string{"White": "?", "Black": "?", "Result": "*"}
+		for _, m := range gTagPairRegex.FindAllStringSubmatch(game, -1) {
+			tags[m[1]] = gTagUnescaper.Replace(m[2])
+		}
+		fmt.Printf("%4d. %s - %s %s %s\n", i+1, tags["White"], tags["Black"], tags["Result"], gConsole.Faint(tags["Date"]))
+	}
+
+	var answer string
+	switch {
+	case l != nil:
+		l.SetPrompt("Load game [1]: ")
+		answer, _ = l.Readline()
+	case filename != "-" && readline.IsTerminal(int(os.Stdin.Fd())):
+		fmt.Print("Load game [1]: ")
+		answer, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(answer)); err == nil && n >= 1 && n <= len(games) {
+		return n - 1
+	}
+	fmt.Println("Loading game 1.")
+	return 0
+}
+
+// Parse a PGN file into a game, asking which one in a database. "-" reads the
+// PGN from the standard input. l asks on the shell's input, nil when there is
+// none yet.
+func readPGN(l *readline.Instance, filename string) *chess.Game {
+	games, err := pgnGames(filename)
 	if err != nil {
 		fmt.Println(gConsole.Bold("Unable to read " + gConsole.Red(filename).String() + "."))
 		return nil
 	}
+	choice := 0
+	if len(games) > 1 {
+		choice = chooseGame(l, filename, games)
+	}
+	pgnDat := []byte(games[choice])
+
+	// The chess package plays standard chess only, the moves of a variant game
+	// like Chess960 would go wrong. Neither does Piñata ask the engine for one.
+	if m := gVariantRegex.FindSubmatch(pgnDat); m != nil && !strings.EqualFold(string(m[1]), "Standard") {
+		fmt.Println(gConsole.Bold(gConsole.Red(filename)), "is a", string(m[1]), "game, Piñata plays standard chess only.")
+		return nil
+	}
 
-	pgn, err := chess.PGN(strings.NewReader(string(pgnDat)))
+	pgn, err := chess.PGN(strings.NewReader(standardMovetext(string(pgnDat))))
 	if err != nil {
 		fmt.Println(gConsole.Bold(gConsole.Red(filename)), "is not a valid PGN file.")
 		return nil
@@ -56,6 +150,64 @@ func loadPGN(filename string) *chess.Game {
 		fmt.Println("Unable to initialize a new game from " + gConsole.Bold(gConsole.Red(filename)).String() + ".")
 		return nil
 	}
+	for _, tag := range game.TagPairs() { // The chess package keeps the escapes.
+		tag.Value = gTagUnescaper.Replace(tag.Value)
+	}
+	// The chess package starts from the FEN tag, a set up game without one is incomplete.
+	if GetTagPair(game, "SetUp") == "1" && GetTagPair(game, "FEN") == "" {
+		fmt.Println(gConsole.Bold(gConsole.Red(filename)), "is set up from a position, but has no FEN tag.")
+		return nil
+	}
+	// The chess package takes the outcome from the result, an unfinished game may
+	// still stand in checkmate or stalemate. Replaying the moves settles it.
+	if game.Outcome() == chess.NoOutcome {
+		switch game.Position().Status() {
+		case chess.Checkmate, chess.Stalemate:
+			game = rewindGame(game, len(game.Moves()))
+		}
+	}
+	return game
+}
+
+// Check whether the saved game is an earlier state of the game, same start and move history.
+func sameGame(saved, game *chess.Game) bool {
+	if saved.Positions()[0].String() != game.Positions()[0].String() {
+		return false
+	}
+	savedMoves, moves := saved.Moves(), game.Moves()
+	if len(savedMoves) > len(moves) {
+		return false
+	}
+	for i, move := range savedMoves {
+		if move.String() != moves[i].String() {
+			return false
+		}
+	}
+	return true
+}
+
+// Check whether saving the game would replace a different game in the file.
+func overwritesOtherGame(game *chess.Game, filename string) bool {
+	pgnDat, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	pgn, err := chess.PGN(strings.NewReader(standardMovetext(string(pgnDat))))
+	if err != nil { // Not even a game, better ask.
+		return true
+	}
+	return !sameGame(chess.NewGame(pgn), game)
+}
+
+// Start a game from a PGN file
+func loadPGN(l *readline.Instance, filename string) *chess.Game {
+	game := readPGN(l, filename)
+	if game == nil {
+		return nil
+	}
 
 	tpAnnotator := GetTagPair(game, "Annotator")
 	tpWhite := GetTagPair(game, "White")
@@ -67,83 +219,421 @@ func loadPGN(filename string) *chess.Game {
 		return nil
 	}
 
-	// Load previous settings.
-	if tpBlack == "Human" { // Human is black.
+	// Load previous settings. The player types tell the human from the engine,
+	// games saved before them call the human "Human".
+	whiteHuman, blackHuman := tpWhite == "Human", tpBlack == "Human"
+	if tpWhiteType, tpBlackType := GetTagPair(game, "WhiteType"), GetTagPair(game, "BlackType"); tpWhiteType != "" || tpBlackType != "" {
+		whiteHuman, blackHuman = tpWhiteType == "human", tpBlackType == "human"
+	}
+	if whiteHuman {
+		restoreName(&gWhiteName, tpWhite)
+	}
+	if blackHuman {
+		restoreName(&gBlackName, tpBlack)
+	}
+
+	if gTwoPlayer || (whiteHuman && blackHuman) { // Nobody plays the engine.
+		gTwoPlayer = true
+		fmt.Println(gConsole.Bold(gConsole.Yellow(tpWhite)).String() + " plays " +
+			gConsole.Bold(gConsole.Yellow(tpBlack)).String() + ".")
+	} else if blackHuman { // Human is black.
 		gHumanIsBlack = true
-		gEngineBinary = tpWhite // Use the same engine as before.
+		restoreEngine(&gWhiteName, tpWhite)
 		fmt.Println("You are playing " + gConsole.Bold(gConsole.Yellow("Black")).String() +
 			" against " + gConsole.Bold(gConsole.Yellow(gEngineBinary)).String() + ".")
 	} else { // Human is white
 		gHumanIsBlack = false
-		gEngineBinary = tpBlack // Use the same engine as before.
+		restoreEngine(&gBlackName, tpBlack)
 		fmt.Println("You are playing " + gConsole.Bold(gConsole.Yellow("White")).String() +
 			" against " + gConsole.Bold(gConsole.Yellow(gEngineBinary)).String() + ".")
 	}
+	gEvals = loadEvals(game, filename)
+	gBookmarks = loadBookmarks(game, filename)
 
 	return game
 }
 
+// Keep the human's name of the saved game, unless named on the command line.
+func restoreName(name *string, tag string) {
+	if *name == "" && tag != "Human" {
+		*name = tag
+	}
+}
+
+// Use the same engine as before. A tag naming no engine is the engine's name,
+// the game goes on with the `--engine`.
+func restoreEngine(name *string, tag string) {
+	if _, err := findEngine(tag); err == nil {
+		gEngineBinary = tag
+		return
+	}
+	restoreName(name, tag)
+}
+
+// Atomically replace the file with the game's PGN. Readers never see a partial file.
+func writePGN(game *chess.Game, filename string) error {
+	return writeFileAtomic(filename, pgnText(game)+"\n")
+}
+
+// With `--dry-run`, print the file that would be written instead. True when
+// the caller has to leave it at that.
+func dryRun(filename string) bool {
+	if gDryRun {
+		fmt.Println("Dry run, would write", gConsole.Bold(gConsole.Yellow(filename)).String()+".")
+	}
+	return gDryRun
+}
+
+// Atomically replace the file by renaming a complete copy over it.
+func writeFileAtomic(filename, data string) error {
+	mode := os.FileMode(0644)
+	if fInfo, err := os.Stat(filename); err == nil {
+		mode = fInfo.Mode().Perm() // Keep the permissions of the file we replace.
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op after a successful rename.
+
+	if _, err = tmp.WriteString(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// FEN last written to the `--fen-file`.
+var gMirroredFEN string
+
+// Mirror the position to the `--fen-file` for external boards, if it changed.
+func mirrorFEN(game *chess.Game) {
+	fen := game.FEN()
+	if gFENFile == "" || fen == gMirroredFEN {
+		return
+	}
+	if err := writeFileAtomic(gFENFile, fen+"\n"); err != nil {
+		fmt.Println("Unable to write the FEN to", gConsole.Bold(gConsole.Red(gFENFile)).String()+",", err)
+		gFENFile = "" // Once is enough.
+		return
+	}
+	gMirroredFEN = fen
+}
+
+// The game's PGN as it would be saved, the game itself stays as is.
+func savedPGNText(game *chess.Game) string {
+	game = game.Clone()
+	tagGame(game)
+	return pgnText(game)
+}
+
+// The game's PGN. Unlike the chess package, the move numbers continue from a
+// set up position, "12...Nf6" when Black moves first.
+func pgnText(game *chess.Game) string {
+	var pgn strings.Builder
+	for _, tag := range game.TagPairs() {
+		fmt.Fprintf(&pgn, "[%s \"%s\"]\n", tag.Key, gTagEscaper.Replace(tag.Value))
+	}
+	pgn.WriteString("\n")
+
+	positions := game.Positions()
+	for i, move := range game.Moves() {
+		pos := positions[i]
+		san := encodeSAN(pos, move)
+		if pos.Turn() == chess.White || i == 0 { // Also when Black moves first.
+			pgn.WriteString(strings.TrimSpace(moveNumber(pos)))
+		}
+		pgn.WriteString(san + " ")
+	}
+	pgn.WriteString(game.Outcome().String())
+	return pgn.String()
+}
+
 // Save the game to a PGN file
 func savePGN(game *chess.Game, filename string) error {
-	file, err := os.Create(filename)
+	tagGame(game)
+
+	// Save the engine name.
+	err := writePGN(game, filename)
 	if err != nil {
-		fmt.Println("Unable to create", gConsole.Bold(gConsole.Red(filename)))
+		fmt.Println("Unable to save the game to", gConsole.Bold(gConsole.Red(filename)).String()+",", err)
 		return err
 	}
-	defer file.Close()
+	saveEvals(game, filename, gEvals)
+	saveBookmarks(game, filename, gBookmarks)
+
+	return nil // Success
+}
+
+// Moves of the game when `--autosave-every` last saved it.
+var gAutosaved int
+
+// Save the game in progress every `--autosave-every` moves, quietly. A file
+// holding another game is left for the final save to ask about.
+func autosave(game *chess.Game, filename string) {
+	moves := len(game.Moves())
+	if moves < gAutosaved { // Taken back.
+		gAutosaved = moves
+	}
+	if gAutosaveEvery == 0 || gDryRun || moves-gAutosaved < gAutosaveEvery {
+		return
+	}
+	if gConfirmSave && overwritesOtherGame(game, filename) {
+		return
+	}
+	if savePGN(game, filename) != nil {
+		fmt.Println(gConsole.Faint("The game goes on unsaved, you will be asked for another file at the end."))
+	}
+	gAutosaved = moves // Not again for another stretch of moves.
+}
 
-	// Generate PGN content.
+// Add the tag pairs of a saved game.
+func tagGame(game *chess.Game) {
 	game.AddTagPair("Annotator", "pinata")
 	curTime := time.Now()
 	curDate := fmt.Sprintf("%d-%02d-%02d", curTime.Year(), curTime.Month(), curTime.Day())
 	game.AddTagPair("Date", curDate)
 	game.AddTagPair("Result", game.Outcome().String())
-	if humanColor() == chess.White {
-		game.AddTagPair("White", "Human")
-		game.AddTagPair("Black", gEngineBinary)
-	} else {
-		game.AddTagPair("White", gEngineBinary)
-		game.AddTagPair("Black", "Human")
+	game.AddTagPair("Termination", termination(game))
+	if start := game.Positions()[0].String(); start != gStandardFEN { // Set up position, see `--fen`.
+		game.AddTagPair("SetUp", "1")
+		game.AddTagPair("FEN", start)
+	}
+	game.AddTagPair("White", playerName(chess.White))
+	game.AddTagPair("Black", playerName(chess.Black))
+	game.AddTagPair("WhiteType", playerType(chess.White))
+	game.AddTagPair("BlackType", playerType(chess.Black))
+	if gClock != nil {
+		if white, black := gClock.TimeControls(); white == black {
+			game.AddTagPair("TimeControl", white)
+		} else { // Time odds.
+			game.AddTagPair("WhiteTimeControl", white)
+			game.AddTagPair("BlackTimeControl", black)
+		}
 	}
+}
 
-	// Save the engine name.
-	_, err = file.WriteString(game.String() + "\n")
-	if err != nil {
-		fmt.Println("Unable to save the game to", gConsole.Bold(gConsole.Red(filename)))
-		return err
+// How the game ended for the PGN Termination tag. A time forfeit or an
+// adjudication is tagged as it happens, mates, draws and resignations are
+// "normal" and a game still going is "unterminated".
+func termination(game *chess.Game) string {
+	if game.Outcome() == chess.NoOutcome {
+		return "unterminated"
+	}
+	if tag := game.GetTagPair("Termination"); tag != nil && tag.Value != "unterminated" {
+		return tag.Value
 	}
+	return "normal"
+}
 
-	return nil // Success
+// Name of the player in the saved game, `--white-name` and `--black-name` or
+// else "White" and "Black" in two-player mode, "Human" and the engine otherwise.
+func playerName(color chess.Color) string {
+	name, twoPlayerName := gWhiteName, "White"
+	if color == chess.Black {
+		name, twoPlayerName = gBlackName, "Black"
+	}
+	switch {
+	case name != "":
+		return name
+	case gTwoPlayer:
+		return twoPlayerName
+	case color == humanColor():
+		return "Human"
+	}
+	if gEngineNickname != "" {
+		return gEngineNickname
+	}
+	return gEngineBinary
+}
+
+// Player type of the saved game, "human" or "program" as the PGN standard has it.
+func playerType(color chess.Color) string {
+	if gTwoPlayer || color == humanColor() {
+		return "human"
+	}
+	return "program"
+}
+
+// Rotate the board when black is facing the human.
+func facingBlack() bool {
+	if gTwoPlayer && !gAutoFlip { // Both players share the white side.
+		return false
+	}
+	return gHumanIsBlack
 }
 
 func drawBoard(game *chess.Game) {
-	if !gVisual {
-		return // playing blind
+	fmt.Print(boardView(game))
+}
+
+// The board in visual mode, the FEN and teaching notes if asked for.
+func boardView(game *chess.Game) string {
+	view := ""
+	if gVisual { // Otherwise playing blind
+		blackSide := facingBlack()
+		var highlight map[chess.Square]bool
+		if gHighlight {
+			highlight = lastMoveSquares(game)
+		}
+		view += renderBoard(game.Position(), blackSide, highlight, arrowMarks(gArrows, blackSide))
 	}
 
-	if gHumanIsBlack { // Rotate the board, black facing the human.
-		fmt.Print(game.Position().Board().DrawForBlack())
-	} else {
-		fmt.Print(game.Position().Board().Draw())
+	if gShowFEN { // Keep it low key, it is meant for external tools.
+		view += gConsole.Faint(game.FEN()).String() + "\n"
+	}
+	view += teachingNote(game)
+	return view
+}
+
+// End a game that reached the `--max-moves` limit. A decisive engine evaluation
+// (from White's side) decides the winner, anything else is a draw. The reason is
+// kept in the PGN and returned, "" if the game goes on.
+func adjudicate(game *chess.Game, info *uciInfo) string {
+	if gMaxMoves <= 0 || len(game.Moves()) < 2*gMaxMoves || game.Outcome() != chess.NoOutcome {
+		return ""
+	}
+
+	reason := "move limit of " + strconv.Itoa(gMaxMoves) + " reached"
+	switch {
+	case info != nil && (info.Mate || info.Score >= gAdjudicateScore) && info.Score > 0:
+		game.Resign(chess.Black)
+		reason += ", White is winning"
+	case info != nil && (info.Mate || info.Score <= -gAdjudicateScore) && info.Score < 0:
+		game.Resign(chess.White)
+		reason += ", Black is winning"
+	default:
+		game.Draw(chess.DrawOffer)
+		reason += ", drawn"
+	}
+
+	game.AddTagPair("Termination", "adjudication")
+	game.AddTagPair("Adjudication", reason)
+	return reason
+}
+
+// How the game ended. An adjudicated game is resigned or drawn by offer to the
+// chess package, the Termination tag tells.
+func endMethod(game *chess.Game) string {
+	if GetTagPair(game, "Termination") == "adjudication" {
+		return "Adjudication"
 	}
+	return game.Method().String()
+}
+
+// New game from the standard starting position or the `--fen` position.
+func newGame() *chess.Game {
+	if gStartFEN == "" {
+		return chess.NewGame(chess.UseNotation(chess.AlgebraicNotation{}))
+	}
+
+	fen, err := chess.FEN(sanitizeFEN(gStartFEN))
+	if err != nil {
+		fmt.Println("Not a valid FEN.")
+		os.Exit(1)
+	}
+	return chess.NewGame(fen, chess.UseNotation(chess.AlgebraicNotation{}))
+}
+
+// Home squares of the king and the rook of each castling right.
+var gCastlingSquares = map[rune][3]chess.Square{
+	'K': {chess.E1, chess.H1}, 'Q': {chess.E1, chess.A1},
+	'k': {chess.E8, chess.H8}, 'q': {chess.E8, chess.A8},
+}
+
+// Drop the castling rights and en passant square the position of the FEN does
+// not bear out, telling which. The chess package takes them on trust and would
+// castle without a rook. Invalid FENs are left for the caller to reject.
+func sanitizeFEN(fenStr string) string {
+	fields := strings.Fields(fenStr)
+	fen, err := chess.FEN(fenStr)
+	if err != nil || len(fields) < 4 {
+		return fenStr
+	}
+	board := chess.NewGame(fen).Position().Board()
+
+	rights, dropped := "", ""
+	for _, right := range fields[2] {
+		squares, ok := gCastlingSquares[right]
+		if !ok { // "-"
+			continue
+		}
+		king, rook := chess.WhiteKing, chess.WhiteRook
+		if right == 'k' || right == 'q' {
+			king, rook = chess.BlackKing, chess.BlackRook
+		}
+		if board.Piece(squares[0]) == king && board.Piece(squares[1]) == rook {
+			rights += string(right)
+		} else {
+			dropped += string(right)
+		}
+	}
+	if dropped != "" {
+		fmt.Println("Castling rights", dropped, "dropped, the king or rook is not at home.")
+		if rights == "" {
+			rights = "-"
+		}
+		fields[2] = rights
+	}
+
+	if ep, err := parseSquare(fields[3]); err == nil {
+		// The pawn that just moved two squares stands past the en passant square.
+		skipped, rank, pawn, before := chess.Rank6, chess.Rank5, chess.BlackPawn, chess.Rank7
+		if fields[1] == "b" {
+			skipped, rank, pawn, before = chess.Rank3, chess.Rank4, chess.WhitePawn, chess.Rank2
+		}
+		passed := chess.Square(int(rank)*8 + int(ep.File()))
+		from := chess.Square(int(before)*8 + int(ep.File()))
+		if ep.Rank() != skipped || board.Piece(passed) != pawn || board.Piece(ep) != chess.NoPiece || board.Piece(from) != chess.NoPiece {
+			fmt.Println("En passant square", fields[3], "dropped, no pawn just moved past it.")
+			fields[3] = "-"
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// The game as it stood after the first plies, same start and tag pairs.
+func rewindGame(game *chess.Game, ply int) *chess.Game {
+	fen, _ := chess.FEN(game.Positions()[0].String())
+	rewound := chess.NewGame(fen, chess.UseNotation(chess.AlgebraicNotation{}), chess.TagPairs(game.TagPairs()))
+	for _, move := range game.Moves()[:ply] {
+		rewound.Move(move)
+	}
+	return rewound
 }
 
 func isGameOver(game *chess.Game) bool {
+	if game == gGame && tablebaseDraw(game, gLastInfo) {
+		game.Draw(chess.DrawOffer)
+		game.AddTagPair("Termination", "adjudication")
+		game.AddTagPair("Adjudication", "tablebase draw")
+	}
 	switch game.Outcome() {
 	case chess.NoOutcome:
 		return false
 	case chess.Draw:
 		fmt.Println(gConsole.Bold(gConsole.Yellow("Game Draw")).String() +
-			" (" + gConsole.Bold(game.Method().String()).String() + ")")
+			" (" + gConsole.Bold(endMethod(game)).String() + ")")
 	case chess.WhiteWon:
 		fmt.Println(gConsole.Bold(gConsole.Yellow("White Won")).String() +
-			" (" + gConsole.Bold(game.Method().String()).String() + ")")
+			" (" + gConsole.Bold(endMethod(game)).String() + ")")
 	case chess.BlackWon:
 		fmt.Println(gConsole.Bold(gConsole.Yellow("Black Won")).String() +
-			" (" + gConsole.Bold(game.Method().String()).String() + ")")
+			" (" + gConsole.Bold(endMethod(game)).String() + ")")
 	default:
 		panic(game.Outcome()) // should never happen.
 	}
+	if game == gGame {
+		printAccuracy(game, gEvals)
+	}
 	return true // The end.
 }
 
@@ -159,18 +649,24 @@ func humanColor() chess.Color {
 // Readline completion of all the valid moves left.
 func validMovesConstructor() func(string) []string {
 	return func(string) (moves []string) {
+		if gNoCompletion {
+			return nil
+		}
 		for _, move := range gGame.Position().ValidMoves() {
-			moveSAN := chess.Encoder.Encode(chess.AlgebraicNotation{}, gGame.Position(), move)
+			moveSAN := safeSAN(gGame.Position(), move)
 			moves = append(moves, moveSAN)
 		}
 		return moves
 	}
 }
 
-// Readline completion of all the valid moves left.
+// All the valid moves left, as listed to the player.
 func validMoves(game *chess.Game) (moves string) {
 	for _, move := range game.Position().ValidMoves() {
-		moves += " " + chess.Encoder.Encode(chess.AlgebraicNotation{}, game.Position(), move)
+		moves += " " + encodeSAN(game.Position(), move)
+		if gDualNotation {
+			moves += " (" + move.String() + ")"
+		}
 	}
 	return moves
 }
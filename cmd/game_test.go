@@ -0,0 +1,193 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+	"github.com/logrusorgru/aurora"
+)
+
+func TestMain(m *testing.M) {
+	gConsole = aurora.NewAurora(false) // Plain messages, as with --no-color.
+	os.Exit(m.Run())
+}
+
+// A game from the FEN, failing the test on a bad one.
+func testGame(t *testing.T, fenStr string) *chess.Game {
+	t.Helper()
+	fen, err := chess.FEN(fenStr)
+	if err != nil {
+		t.Fatalf("bad FEN %q: %v", fenStr, err)
+	}
+	return chess.NewGame(fen, chess.UseNotation(chess.AlgebraicNotation{}))
+}
+
+func TestSanitizeFEN(t *testing.T) {
+	tests := []struct {
+		name, fen, want string
+	}{
+		{"partial rights", "r3k2r/8/8/8/8/8/8/R3K2R w Kq - 0 1", "r3k2r/8/8/8/8/8/8/R3K2R w Kq - 0 1"},
+		{"rooks missing", "4k2r/8/8/8/8/8/8/R3K3 w KQkq - 0 1", "4k2r/8/8/8/8/8/8/R3K3 w Qk - 0 1"},
+		{"no rights left", "4k3/8/8/8/8/8/8/4K3 w KQ - 0 1", "4k3/8/8/8/8/8/8/4K3 w - - 0 1"},
+		{"king moved", "r3k2r/8/8/8/8/8/8/R4K1R w KQkq - 0 1", "r3k2r/8/8/8/8/8/8/R4K1R w kq - 0 1"},
+		{"en passant white", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3"},
+		{"en passant black", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"},
+		{"no pawn passed", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 3", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq - 0 3"},
+		{"wrong rank", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e6 0 1", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"},
+		{"invalid FEN", "not a fen", "not a fen"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFEN(tt.fen); got != tt.want {
+			t.Errorf("%s: sanitizeFEN(%q) = %q, want %q", tt.name, tt.fen, got, tt.want)
+		}
+	}
+}
+
+// A game set up from a sanitized FEN is saved and read back the same.
+func TestSanitizedFENRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pinata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "game.pgn")
+
+	for _, fen := range []string{
+		"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+		"4k2r/8/8/8/8/8/8/R3K3 w KQkq - 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 3",
+	} {
+		game := testGame(t, sanitizeFEN(fen))
+		for _, moveStr := range []string{"O-O-O", "exf6", "Kd2"} {
+			if move, err := decodeMove(game, moveStr); err == nil {
+				game.Move(move)
+				break
+			}
+		}
+		tagGame(game)
+		if err := writePGN(game, filename); err != nil {
+			t.Fatal(err)
+		}
+
+		read := readPGN(nil, filename)
+		if read == nil {
+			t.Fatalf("%s: the saved game does not read back:\n%s", fen, pgnText(game))
+		}
+		if got, want := read.Positions()[0].String(), game.Positions()[0].String(); got != want {
+			t.Errorf("%s: start read back as %q, want %q", fen, got, want)
+		}
+		if got, want := read.FEN(), game.FEN(); got != want {
+			t.Errorf("%s: position read back as %q, want %q", fen, got, want)
+		}
+	}
+}
+
+func TestAdjudicatedEndMethod(t *testing.T) {
+	defer func(old int) { gMaxMoves = old }(gMaxMoves)
+	gMaxMoves = 1
+	game := testGame(t, gStandardFEN)
+	game.MoveStr("e4")
+	game.MoveStr("e5")
+	if reason := adjudicate(game, &uciInfo{Score: 600}); reason == "" {
+		t.Fatal("game not adjudicated at the move limit")
+	}
+	if got := endMethod(game); got != "Adjudication" {
+		t.Errorf("endMethod = %q, want Adjudication", got)
+	}
+	if got := endMethod(testGame(t, "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")); got != "Checkmate" {
+		t.Errorf("endMethod of a mate = %q, want Checkmate", got)
+	}
+}
+
+// A level score is a tablebase draw only with tablebase hits, with `--syzygy`,
+// and for the move the engine searched.
+func TestTablebaseDraw(t *testing.T) {
+	defer func(game *chess.Game, info *uciInfo, path string) {
+		gGame, gLastInfo, gSyzygyPath = game, info, path
+	}(gGame, gLastInfo, gSyzygyPath)
+	tests := []struct {
+		name, syzygy, info string
+		over               bool
+	}{
+		{"tablebase draw", "/tb", "info depth 20 score cp 0 nodes 900 tbhits 12 pv e1d1 e8d8", true},
+		{"no tablebase hits", "/tb", "info depth 20 score cp 0 nodes 900 tbhits 0 pv e1d1 e8d8", false},
+		{"no --syzygy", "", "info depth 20 score cp 0 nodes 900 tbhits 12 pv e1d1 e8d8", false},
+		{"not level", "/tb", "info depth 20 score cp 35 nodes 900 tbhits 12 pv e1d1 e8d8", false},
+		{"bound", "/tb", "info depth 20 score cp 0 upperbound nodes 900 tbhits 12 pv e1d1 e8d8", false},
+		{"other move played", "/tb", "info depth 20 score cp 0 nodes 900 tbhits 12 pv e1f1 e8d8", false},
+	}
+	for _, tt := range tests {
+		info, ok := parseUCIInfo(tt.info)
+		if !ok {
+			t.Fatalf("%s: bad info %q", tt.name, tt.info)
+		}
+		gGame = testGame(t, "4k3/4r3/8/8/8/8/4R3/4K3 w - - 0 1")
+		gGame.MoveStr("Kd1")
+		gLastInfo, gSyzygyPath = &info, tt.syzygy
+		if over := isGameOver(gGame); over != tt.over {
+			t.Errorf("%s: isGameOver = %v, want %v", tt.name, over, tt.over)
+		}
+		if tt.over && (gGame.Outcome() != chess.Draw || endMethod(gGame) != "Adjudication") {
+			t.Errorf("%s: game ended %s by %s, want a draw by adjudication", tt.name, gGame.Outcome(), endMethod(gGame))
+		}
+	}
+}
+
+// Quotes and backslashes in tag values are escaped and read back.
+func TestTagEscapeRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pinata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "game.pgn")
+
+	game := testGame(t, gStandardFEN)
+	game.MoveStr("e4")
+	tagGame(game)
+	white, event := `Viswanathan "Vishy" Anand`, `C:\Games\ "Open" \`
+	game.AddTagPair("White", white)
+	game.AddTagPair("Event", event)
+	pgn := pgnText(game)
+	if want := `[White "Viswanathan \"Vishy\" Anand"]`; !strings.Contains(pgn, want) {
+		t.Errorf("PGN has no %s:\n%s", want, pgn)
+	}
+	if err := writePGN(game, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	read := readPGN(nil, filename)
+	if read == nil {
+		t.Fatalf("the saved game does not read back:\n%s", pgn)
+	}
+	if got := GetTagPair(read, "White"); got != white {
+		t.Errorf("White read back as %q, want %q", got, white)
+	}
+	if got := GetTagPair(read, "Event"); got != event {
+		t.Errorf("Event read back as %q, want %q", got, event)
+	}
+	if got := len(read.Moves()); got != 1 {
+		t.Errorf("read back %d moves, want 1", got)
+	}
+}
@@ -17,35 +17,338 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/abperiasamy/chess"
 	"github.com/logrusorgru/aurora"
 )
 
 // Global constants
 const (
-	gVersion      = "1.11"
-	gGameFilename = "pinata.pgn"
+	gVersion             = "1.11"
+	gDefaultGameFilename = "pinata.pgn"
+
+	gAdjudicateScore = 500 // Centipawn advantage that decides an adjudicated game.
 )
 
 // Global defaults. Avoid global variables as much as possible.
 var (
-	gCfgFile        string
-	gGamePath       string
-	gEngineBinary   string
-	gLichessAuthTok string
-	gEngineDepth    int
-	gHumanIsBlack   bool
-	gVisual         bool
-	gNoColor        bool
-	gLightBg        bool
-	gConsole        aurora.Aurora
-	gMoveCount      int = 1 // Increment on every black's move.
-
-	gGame *chess.Game
+	gGameFilename      = gDefaultGameFilename // Another file once the default could not be written.
+	gCfgFile           string
+	gGamePath          string
+	gStartFEN          string
+	gStartName         string
+	gEngineBinary      string
+	gEngineLogFile     string
+	gSessionLogFile    string
+	gLichessAuthTok    string
+	gEngineDepth       int
+	gEngineNodes       int
+	gTimeControl       string
+	gTimeOdds          string
+	gTCStyle           string
+	gSyzygyPath        string
+	gDeadDraws         bool
+	gDrawOffers        int
+	gAutoResign        int
+	gAutoResignMoves   int
+	gMateNotify        int
+	gMateResign        int
+	gContempt          string
+	gHash              int
+	gBlindfoldMoves    int
+	gStatsFile         string
+	gThreads           int
+	gMaxMoves          int
+	gRepertoireFile    string
+	gMovesFile         string
+	gOpeningMoves      string
+	gOnIllegal         string
+	gSeed              int64
+	gVariety           int
+	gTeach             bool
+	gNotesFile         string
+	gLocaleNames       string
+	gLocales           []string // Parsed `--san-locales`.
+	gConfirmSave       bool
+	gAutosaveEvery     int
+	gHumanIsBlack      bool
+	gVisual            bool
+	gWatch             bool
+	gWatchDelay        time.Duration
+	gMinReplyTime      time.Duration
+	gTwoPlayer         bool
+	gAutoFlip          bool
+	gWhiteName         string
+	gBlackName         string
+	gShowFEN           bool
+	gDualNotation      bool
+	gNoCompletion      bool
+	gCastling          string
+	gEnPassant         string
+	gDryRun            bool
+	gNoSaveConfig      bool
+	gEvalPerspective   string
+	gBoardLabels       string
+	gBoardBorder       string
+	gEngineConfigured  bool // `--engine` given on the command-line, in the config file or the profile.
+	gThreadsConfigured bool // `--threads` given on the command-line, in the config file or the profile.
+	gProfile           string
+	gEngineNickname    string            // Engine's name in saved games, from the profile.
+	gEngineOptions     map[string]string // UCI options of the profile.
+	gHistoryFile       string
+	gOnlyMoves         string
+	gFENFile           string
+	gHighlight         bool
+	gCheckHighlight    bool
+	gCheckMarks        string
+	gPalette           string
+	gDim               bool
+	gThinking          bool
+	gVerbose           bool
+	gExplain           bool
+	gMoveDelta         bool
+	gPremove           bool
+	gNoColor           bool
+	gColorMode         string
+	gLightBg           bool
+	gPromptTemplate    string = gDefaultPrompt
+	gConsole           aurora.Aurora
+	gMoveCount         int = 1 // Increment on every black's move.
+
+	gGame    *chess.Game
+	gSandbox *chess.Game // The real game while exploring a line in the sandbox.
+
+	gRepertoire *repertoire // nil when not drilling a repertoire.
+	gEngineLog  *engineLog  // nil unless logging the engine conversation.
+	gSessionLog *sessionLog // nil unless keeping a diary of the session.
+	gClock      *chessClock // nil when playing without a clock.
+
+	gLastInfo   *uciInfo    // Engine's evaluation behind its last move from White's side, if reported.
+	gSearchNote string      // Explanation of the engine's last search with `--verbose`.
+	gEvals      []evalPoint // Engine's evaluations over the course of the game.
+	gArrows     []arrow     // Arrows drawn on the visual board.
 )
 
 // Called before starting the shell.
 func initGlobals() {
+	// Resolve the color mode. `--no-color` always wins.
+	switch gColorMode {
+	case "always":
+	case "never":
+		gNoColor = true
+	case "auto":
+		if !colorSupported() { // Piped or dumb terminal.
+			gNoColor = true
+		}
+	default:
+		fmt.Println("Invalid --color value " + strconv.Quote(gColorMode) + ". Allowed values are [auto|always|never].")
+		os.Exit(1)
+	}
+
 	// Use for color printing
 	gConsole = aurora.NewAurora(!gNoColor)
+	if gDim && !gNoColor {
+		gConsole = dimAurora{gConsole}
+	}
+
+	// Set up the chess clock.
+	if gTimeControl != "" || gTimeOdds != "" {
+		clock, err := setupClock(gTimeControl, gTimeOdds)
+		if err != nil {
+			fmt.Println("Unable to set up the clock,", err)
+			os.Exit(1)
+		}
+		gClock = clock
+	}
+	if gMovesFile != "" && gOpeningMoves != "" {
+		fmt.Println("Use either --moves or --opening-moves, not both.")
+		os.Exit(1)
+	}
+	if gStartFEN != "" && gGamePath != "" {
+		fmt.Println("Use either --fen or --file, not both.")
+		os.Exit(1)
+	}
+
+	switch gOnIllegal {
+	case "abort", "skip", "stop":
+	default:
+		fmt.Println("Invalid --on-illegal value " + strconv.Quote(gOnIllegal) + ". Allowed values are [abort|skip|stop].")
+		os.Exit(1)
+	}
+
+	if gTwoPlayer && gWatch {
+		fmt.Println("Use either --two-player or --watch, not both.")
+		os.Exit(1)
+	}
+
+	if gEngineLogFile != "" {
+		log, err := openEngineLog(gEngineLogFile)
+		if err != nil {
+			fmt.Println("Unable to open the engine log,", err)
+			os.Exit(1)
+		}
+		gEngineLog = log
+	}
+
+	if gSessionLogFile != "" {
+		log, err := openSessionLog(gSessionLogFile)
+		if err != nil {
+			fmt.Println("Unable to open the session log,", err)
+			os.Exit(1)
+		}
+		gSessionLog = log
+	}
+
+	for _, locale := range strings.Split(gLocaleNames, ",") {
+		if locale = strings.TrimSpace(locale); locale == "" {
+			continue
+		}
+		if _, ok := gSANLocales[locale]; !ok {
+			fmt.Println("Invalid --san-locales value " + strconv.Quote(locale) + ". Allowed values are [de|nl|fr|es|it].")
+			os.Exit(1)
+		}
+		gLocales = append(gLocales, locale)
+	}
+
+	if gTeach || gNotesFile != "" {
+		notes, err := loadNotes(gNotesFile)
+		if err != nil {
+			fmt.Println("Unable to load the teaching notes,", err)
+			os.Exit(1)
+		}
+		gNotes = notes
+	}
+
+	if err := validatePrompt(gPromptTemplate); err != nil {
+		fmt.Println("Invalid prompt template,", err)
+		os.Exit(1)
+	}
+
+	if _, err := strconv.Atoi(gContempt); gContempt != "" && err != nil {
+		fmt.Println("Invalid --contempt value " + strconv.Quote(gContempt) + ". Use centipawns, e.g. 20 to avoid draws or -20 to seek them.")
+		os.Exit(1)
+	}
+
+	if gBlindfoldMoves < 0 {
+		fmt.Println("Invalid --blindfold-test value " + strconv.Itoa(gBlindfoldMoves) + ". Use a number of moves, or 0 for no test.")
+		os.Exit(1)
+	}
+	if gBlindfoldMoves > 0 && gVisual {
+		fmt.Println("The blindfold test is played blind, leave out --visual.")
+		os.Exit(1)
+	}
+
+	if gAutosaveEvery < 0 {
+		fmt.Println("Invalid --autosave-every value " + strconv.Itoa(gAutosaveEvery) + ". Use a number of moves, or 0 to save only at the end.")
+		os.Exit(1)
+	}
+
+	if gVariety < 0 || gVariety > 100 {
+		fmt.Println("Invalid --variety value " + strconv.Itoa(gVariety) + ". Use 0 to 100, 0 always plays the best move.")
+		os.Exit(1)
+	}
+
+	if gHash < 0 {
+		fmt.Println("Invalid --hash value " + strconv.Itoa(gHash) + ". Use megabytes, or 0 for the engine's default.")
+		os.Exit(1)
+	}
+	if gThreads < 1 {
+		fmt.Println("Invalid --threads value " + strconv.Itoa(gThreads) + ". The engine needs at least 1 thread.")
+		os.Exit(1)
+	}
+
+	switch gBoardLabels {
+	case "top-left", "top-right", "bottom-left", "bottom-right", "both", "none":
+	default:
+		fmt.Println("Invalid --board-labels value " + strconv.Quote(gBoardLabels) + ". Allowed values are [top-left|top-right|bottom-left|bottom-right|both|none].")
+		os.Exit(1)
+	}
+
+	switch gBoardBorder {
+	case "grid", "outline", "none":
+	default:
+		fmt.Println("Invalid --board-border value " + strconv.Quote(gBoardBorder) + ". Allowed values are [grid|outline|none].")
+		os.Exit(1)
+	}
+
+	switch gEvalPerspective {
+	case "white", "human":
+	default:
+		fmt.Println("Invalid --eval-perspective value " + strconv.Quote(gEvalPerspective) + ". Allowed values are [white|human].")
+		os.Exit(1)
+	}
+
+	switch gCastling {
+	case "O-O", "0-0":
+	default:
+		fmt.Println("Invalid --castling value " + strconv.Quote(gCastling) + ". Allowed values are [O-O|0-0].")
+		os.Exit(1)
+	}
+
+	switch gEnPassant {
+	case "", "e.p.":
+	default:
+		fmt.Println("Invalid --en-passant value " + strconv.Quote(gEnPassant) + ". Allowed values are [e.p.].")
+		os.Exit(1)
+	}
+
+	switch gOnlyMoves {
+	case "", "before", "after":
+	default:
+		fmt.Println("Invalid --only-moves value " + strconv.Quote(gOnlyMoves) + ". Allowed values are [before|after].")
+		os.Exit(1)
+	}
+
+	if gAutoResign < 0 || gAutoResign > gEvalCap {
+		fmt.Println("Invalid --auto-resign value " + strconv.Itoa(gAutoResign) + ". Use centipawns up to " + strconv.Itoa(gEvalCap) + ", or 0 to never offer.")
+		os.Exit(1)
+	}
+	if gAutoResignMoves < 1 {
+		fmt.Println("Invalid --auto-resign-moves value " + strconv.Itoa(gAutoResignMoves) + ". Use a positive number of moves.")
+		os.Exit(1)
+	}
+
+	if gMateNotify < 0 {
+		fmt.Println("Invalid --mate-notify value " + strconv.Itoa(gMateNotify) + ". Use a positive number of plies or 0 for none.")
+		os.Exit(1)
+	}
+
+	if gMateResign < 0 {
+		fmt.Println("Invalid --mate-resign value " + strconv.Itoa(gMateResign) + ". Use a positive number of plies or 0 for none.")
+		os.Exit(1)
+	}
+
+	if gDrawOffers < 0 {
+		fmt.Println("Invalid --draw-offers value " + strconv.Itoa(gDrawOffers) + ". Use a positive number of moves or 0 for none.")
+		os.Exit(1)
+	}
+
+	if gEngineNodes < 0 {
+		fmt.Println("Invalid --nodes value " + strconv.Itoa(gEngineNodes) + ". Use a positive nodes limit or 0 for none.")
+		os.Exit(1)
+	}
+
+	switch gPalette {
+	case "default", "cb":
+	default:
+		fmt.Println("Invalid --palette value " + strconv.Quote(gPalette) + ". Allowed values are [default|cb].")
+		os.Exit(1)
+	}
+
+	if strings.Count(gCheckMarks, ",") != 1 {
+		fmt.Println("Invalid --check-marks value " + strconv.Quote(gCheckMarks) + ". Use the check and checkmate marks separated by a comma, like +,#.")
+		os.Exit(1)
+	}
+
+	switch gTCStyle {
+	case "aggressive", "normal", "conservative":
+	default:
+		fmt.Println("Invalid --tc-style value " + strconv.Quote(gTCStyle) + ". Allowed values are [aggressive|normal|conservative].")
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,188 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+const (
+	gEvalCap     = 1000 // Centipawns, mates and crushing scores are capped so the swings stay visible.
+	gGraphHeight = 11
+	gGraphWidth  = 60
+)
+
+// Engine's evaluation at a move, in centipawns from White's side.
+type evalPoint struct {
+	move  int
+	ply   int // Moves played before the position, 0 if not known.
+	score int
+}
+
+// Are evaluations shown from the human's side, see `--eval-perspective`? Not
+// when nobody or both sides are human.
+func humanPerspective() bool {
+	return gEvalPerspective == "human" && !gTwoPlayer && !gWatch
+}
+
+// The score from White's side as shown, from the human's side if asked for.
+func perspectiveScore(score int) int {
+	if humanPerspective() && humanColor() == chess.Black {
+		return -score
+	}
+	return score
+}
+
+// Whose side the evaluations are shown from.
+func perspectiveLabel() string {
+	if humanPerspective() {
+		return "for you"
+	}
+	return "for White"
+}
+
+// The score of the side to move, as shown with its perspective, "+0.35 for White".
+func formatEval(game *chess.Game, info uciInfo) string {
+	if game.Position().Turn() == chess.Black {
+		info.Score = -info.Score
+	}
+	info.Score = perspectiveScore(info.Score)
+	return formatScore(info) + " " + perspectiveLabel()
+}
+
+// Record the engine's evaluation behind its move.
+func recordEval(game *chess.Game, info *uciInfo) {
+	if info == nil {
+		return
+	}
+	score := info.Score
+	if info.Mate {
+		score = gEvalCap
+		if info.Score < 0 {
+			score = -gEvalCap
+		}
+	}
+	if score > gEvalCap {
+		score = gEvalCap
+	} else if score < -gEvalCap {
+		score = -gEvalCap
+	}
+
+	fields := strings.Fields(game.FEN()) // The last FEN field is the full move number.
+	move, _ := strconv.Atoi(fields[len(fields)-1])
+	gEvals = append(gEvals, evalPoint{move: move, ply: len(game.Moves()), score: score})
+}
+
+// Evaluations recorded before the full move number.
+func evalsBefore(evals []evalPoint, move int) []evalPoint {
+	var kept []evalPoint
+	for _, e := range evals {
+		if e.move < move {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// Print the evaluation over the course of the game, White's advantage above
+// the axis, or the human's with `--eval-perspective human`.
+func printEvalGraph(evals []evalPoint) {
+	if len(evals) < 2 {
+		return
+	}
+	shown := make([]evalPoint, len(evals))
+	for i, e := range evals {
+		shown[i] = evalPoint{move: e.move, score: perspectiveScore(e.score)}
+	}
+	evals = shown
+
+	// Average the evaluations of long games into the graph width.
+	if len(evals) > gGraphWidth {
+		buckets := make([]evalPoint, gGraphWidth)
+		for i := range buckets {
+			from, to := i*len(evals)/gGraphWidth, (i+1)*len(evals)/gGraphWidth
+			sum := 0
+			for _, e := range evals[from:to] {
+				sum += e.score
+			}
+			buckets[i] = evalPoint{move: evals[from].move, score: sum / (to - from)}
+		}
+		evals = buckets
+	}
+
+	lo, hi := 0, 0
+	for _, e := range evals {
+		if e.score < lo {
+			lo = e.score
+		}
+		if e.score > hi {
+			hi = e.score
+		}
+	}
+	if hi == lo {
+		hi = lo + 100
+	}
+	row := func(score int) int {
+		return int(math.Round(float64(hi-score) / float64(hi-lo) * (gGraphHeight - 1)))
+	}
+
+	marker := "●"
+	if gNoColor {
+		marker = "*"
+	}
+	zero := row(0)
+	for r := 0; r < gGraphHeight; r++ {
+		label := ""
+		switch r {
+		case zero:
+			label = "0.0"
+		case 0:
+			label = fmt.Sprintf("%+.1f", float64(hi)/100)
+		case gGraphHeight - 1:
+			label = fmt.Sprintf("%+.1f", float64(lo)/100)
+		}
+
+		var line strings.Builder
+		for _, e := range evals {
+			switch {
+			case row(e.score) == r && e.score >= 0:
+				line.WriteString(gConsole.Bold(marker).String())
+			case row(e.score) == r:
+				line.WriteString(gConsole.Faint(marker).String())
+			case r == zero:
+				line.WriteString("─")
+			default:
+				line.WriteString(" ")
+			}
+		}
+		fmt.Printf("%6s │%s\n", label, line.String())
+	}
+
+	// Move numbers every 10 columns.
+	axis := []byte(strings.Repeat(" ", len(evals)+4))
+	for i := 0; i < len(evals); i += 10 {
+		copy(axis[i:], strconv.Itoa(evals[i].move))
+	}
+	fmt.Printf("%6s └%s\n", "", strings.Repeat("─", len(evals)))
+	fmt.Printf("%6s  %s\n", "move", strings.TrimRight(string(axis), " "))
+	fmt.Println(gConsole.Faint(fmt.Sprintf("%6s  Above the axis is good %s.", "", perspectiveLabel())))
+}
@@ -0,0 +1,272 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abperiasamy/chess"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gMatchGames       int
+	gMatchWhite       string
+	gMatchBlack       string
+	gMatchConcurrency int
+	gMatchDir         string
+)
+
+// Outcome of a single match game.
+type matchResult struct {
+	round   int
+	white   string
+	black   string
+	outcome chess.Outcome
+	method  string
+	err     error // The game crashed and does not count.
+}
+
+// The first engine plays White in the odd rounds, the colors swap every game.
+// Both engines may be the same binary, so the round tells them apart.
+func (res matchResult) firstIsWhite() bool {
+	return res.round%2 == 1
+}
+
+// matchCmd plays a series of engine vs engine games.
+var matchCmd = &cobra.Command{
+	Use:     "match",
+	Short:   "Play a match between two engines and report the score",
+	Example: `  pinata match --games 20 --white-engine stockfish --black-engine fairy-stockfish --concurrency 2`,
+	Args:    cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		if gMatchGames < 1 || gMatchConcurrency < 1 {
+			fmt.Println("Both --games and --concurrency need to be at least 1.")
+			os.Exit(1)
+		}
+
+		// Resolve both engines upfront, a typo should not cost a game.
+		engines := [2]string{}
+		for i, name := range []string{gMatchWhite, gMatchBlack} {
+			path, err := findEngine(name)
+			if err != nil {
+				fmt.Println("Unable to find " + gConsole.Bold(gConsole.Red(name)).String() + ".")
+				os.Exit(1)
+			}
+			engines[i] = path
+		}
+		if gDryRun {
+			planMatch(engines[0], engines[1])
+			return
+		}
+		if err := os.MkdirAll(gMatchDir, 0755); err != nil {
+			fmt.Println("Unable to create", gConsole.Bold(gConsole.Red(gMatchDir)))
+			os.Exit(1)
+		}
+
+		results := make([]matchResult, gMatchGames)
+		var wg sync.WaitGroup
+		var mu sync.Mutex                           // Serializes the progress report.
+		slots := make(chan bool, gMatchConcurrency) // Games played in parallel.
+		for round := 1; round <= gMatchGames; round++ {
+			wg.Add(1)
+			slots <- true
+			go func(round int) {
+				defer func() { <-slots; wg.Done() }()
+
+				// Swap colors every game.
+				white, black := engines[0], engines[1]
+				if round%2 == 0 {
+					white, black = black, white
+				}
+				res := playMatchGame(round, white, black)
+				results[round-1] = res
+
+				mu.Lock()
+				defer mu.Unlock()
+				if res.err != nil {
+					fmt.Println("Game", round, gConsole.Bold(gConsole.Red("failed")).String()+":", res.err)
+					return
+				}
+				fmt.Printf("Game %d: %s vs %s %s (%s)\n", round, white, black,
+					gConsole.Bold(gConsole.Yellow(res.outcome)), res.method)
+			}(round)
+		}
+		wg.Wait()
+
+		printMatchScore(engines[0], engines[1], results)
+	},
+}
+
+// Play a single game between two engines and save it. A crashing engine only loses this game.
+func playMatchGame(round int, white, black string) (res matchResult) {
+	res = matchResult{round: round, white: white, black: black}
+	defer func() {
+		if r := recover(); r != nil {
+			res.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	players := map[chess.Color]*uciEngine{}
+	for color, path := range map[chess.Color]string{chess.White: white, chess.Black: black} {
+		eng, err := newUCIEngine(path)
+		if err != nil {
+			res.err = err
+			return res
+		}
+		defer eng.Close()
+		if err = eng.IsReady(); err != nil {
+			res.err = err
+			return res
+		}
+		players[color] = eng
+	}
+
+	game := chess.NewGame()
+	params := matchLimits()
+	for game.Outcome() == chess.NoOutcome {
+		color := game.Position().Turn()
+		move, results, err := searchMove(players[color], game, params)
+		if err != nil {
+			res.err = fmt.Errorf("%s: %v", filepath.Base(players[color].cmd.Path), err)
+			return res
+		}
+		if move == nil { // No legal moves left.
+			break
+		}
+		if err = game.Move(move); err != nil {
+			res.err = fmt.Errorf("%s played an illegal move %s", filepath.Base(players[color].cmd.Path), move)
+			return res
+		}
+		adjudicate(game, whiteInfo(results, color))
+	}
+
+	curTime := time.Now()
+	game.AddTagPair("Event", "Piñata match")
+	game.AddTagPair("Round", strconv.Itoa(round))
+	game.AddTagPair("Annotator", "pinata")
+	game.AddTagPair("Date", fmt.Sprintf("%d-%02d-%02d", curTime.Year(), curTime.Month(), curTime.Day()))
+	game.AddTagPair("Result", game.Outcome().String())
+	game.AddTagPair("Termination", termination(game))
+	game.AddTagPair("White", white)
+	game.AddTagPair("Black", black)
+	filename := filepath.Join(gMatchDir, fmt.Sprintf("match-%03d.pgn", round))
+	if err := writePGN(game, filename); err != nil {
+		fmt.Println("Unable to save the game to", gConsole.Bold(gConsole.Red(filename)))
+	}
+
+	res.outcome = game.Outcome()
+	res.method = endMethod(game)
+	return res
+}
+
+// Search limits of the match games.
+func matchLimits() string {
+	if params := searchLimits(); params != "" {
+		return params
+	}
+	return "depth 10" // Matches are played by depth or nodes, the clock does not apply.
+}
+
+// The games `--dry-run` would play, with the colors and where each is saved.
+func planMatch(first, second string) {
+	params := matchLimits()
+	for round := 1; round <= gMatchGames; round++ {
+		white, black := first, second
+		if round%2 == 0 {
+			white, black = black, white
+		}
+		fmt.Printf("Game %d: %s vs %s (%s), saved to %s\n", round, white, black, params,
+			filepath.Join(gMatchDir, fmt.Sprintf("match-%03d.pgn", round)))
+	}
+}
+
+// Print the first engine's score with the Elo difference and its 95% confidence interval.
+func printMatchScore(first, second string, results []matchResult) {
+	var scores []float64 // From the first engine's side.
+	wins, draws, losses := 0, 0, 0
+	for _, res := range results {
+		if res.err != nil {
+			continue
+		}
+		score := 0.5
+		switch {
+		case res.outcome == chess.WhiteWon && res.firstIsWhite(), res.outcome == chess.BlackWon && !res.firstIsWhite():
+			score = 1
+			wins++
+		case res.outcome == chess.Draw:
+			draws++
+		default:
+			score = 0
+			losses++
+		}
+		scores = append(scores, score)
+	}
+	if len(scores) == 0 {
+		fmt.Println("No games completed.")
+		return
+	}
+
+	n := float64(len(scores))
+	mean := 0.0
+	for _, s := range scores {
+		mean += s
+	}
+	mean /= n
+	variance := 0.0
+	for _, s := range scores {
+		variance += (s - mean) * (s - mean)
+	}
+	margin := 1.96 * math.Sqrt(variance/n) / math.Sqrt(n)
+
+	fmt.Printf("%s vs %s: +%d =%d -%d, score %.1f/%d (%.1f%%)\n", first, second, wins, draws, losses, mean*n, len(scores), mean*100)
+	elo := eloDiff(mean)
+	low, high := eloDiff(mean-margin), eloDiff(mean+margin)
+	if math.IsInf(elo, 0) {
+		fmt.Println("Elo difference: not measurable from a clean sweep, play more games.")
+		return
+	}
+	fmt.Printf("Elo difference: %+.0f (95%% confidence %+.0f to %+.0f)\n", elo, low, high)
+}
+
+// Elo difference implied by the expected score.
+func eloDiff(score float64) float64 {
+	if score <= 0 {
+		return math.Inf(-1)
+	}
+	if score >= 1 {
+		return math.Inf(1)
+	}
+	return 400 * math.Log10(score/(1-score))
+}
+
+func init() {
+	matchCmd.Flags().IntVarP(&gMatchGames, "games", "n", 10, "number of games, the engines swap colors every game")
+	matchCmd.Flags().StringVar(&gMatchWhite, "white-engine", "stockfish", "engine playing white in the first game")
+	matchCmd.Flags().StringVar(&gMatchBlack, "black-engine", "stockfish", "engine playing black in the first game")
+	matchCmd.Flags().IntVarP(&gMatchConcurrency, "concurrency", "j", 1, "number of games to play in parallel")
+	matchCmd.Flags().StringVar(&gMatchDir, "dir", ".", "directory to save the games in")
+	rootCmd.AddCommand(matchCmd)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+const gLowMobility = 1 // Squares a piece reaches at most to be hemmed in.
+
+// Squares each of the color's pieces can move to, by the square it stands on.
+// Promotions to different pieces count once.
+func pieceMobility(pos *chess.Position, color chess.Color) map[chess.Square]int {
+	if pos.Turn() != color {
+		if pos = withTurn(pos, color); pos == nil {
+			return nil
+		}
+	}
+	mobility := map[chess.Square]int{}
+	for sq, piece := range pos.Board().SquareMap() {
+		if piece.Color() == color {
+			mobility[sq] = 0
+		}
+	}
+	seen := map[[2]chess.Square]bool{}
+	for _, move := range pos.ValidMoves() {
+		if key := [2]chess.Square{move.S1(), move.S2()}; !seen[key] {
+			seen[key] = true
+			mobility[move.S1()]++
+		}
+	}
+	return mobility
+}
+
+// Hemmed in, a piece with few squares to go to. Pawns and the king are
+// often meant to stay put.
+func lowMobility(squares map[chess.Square]chess.Piece, sq chess.Square, moves int) bool {
+	kind := squares[sq].Type()
+	return kind != chess.Pawn && kind != chess.King && moves <= gLowMobility
+}
+
+// Show how many squares each of the human's pieces can move to for
+// `/mobility`: on the board in visual mode, the hemmed in pieces highlighted,
+// or as a list playing blind.
+func printMobility(game *chess.Game) {
+	pos := game.Position()
+	squares := pos.Board().SquareMap()
+	mobility := pieceMobility(pos, humanColor())
+
+	var sqs []chess.Square
+	for sq := range mobility {
+		sqs = append(sqs, sq)
+	}
+	sort.Slice(sqs, func(i, j int) bool { // Least mobile first.
+		if mobility[sqs[i]] != mobility[sqs[j]] {
+			return mobility[sqs[i]] < mobility[sqs[j]]
+		}
+		return sqs[i] < sqs[j]
+	})
+
+	var low []string
+	for _, sq := range sqs {
+		if lowMobility(squares, sq, mobility[sq]) {
+			low = append(low, pieceOn(squares, sq)+" ("+strconv.Itoa(mobility[sq])+")")
+		}
+	}
+
+	if gVisual {
+		highlight := map[chess.Square]bool{}
+		marks := map[chess.Square]string{}
+		for sq, moves := range mobility {
+			marks[sq] = strconv.Itoa(moves)
+			highlight[sq] = lowMobility(squares, sq, moves)
+		}
+		fmt.Print(renderBoard(pos, facingBlack(), highlight, marks))
+	} else {
+		for _, sq := range sqs {
+			name := pieceOn(squares, sq)
+			if lowMobility(squares, sq, mobility[sq]) {
+				name = gConsole.Bold(gConsole.Red(name)).String()
+			}
+			fmt.Printf("  %-6s %d\n", name, mobility[sq])
+		}
+	}
+
+	if len(low) == 0 {
+		fmt.Println("None of your pieces is short of squares.")
+		return
+	}
+	fmt.Println("Short of squares:", gConsole.Bold(gConsole.Red(strings.Join(low, ", "))))
+}
@@ -0,0 +1,326 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// SAN piece move without the disambiguation it needs, e.g. "Nd2" or "Rxe1".
+var gPieceMoveRegex = regexp.MustCompile(`^([KQRBN])([a-h])?([1-8])?x?([a-h][1-8])$`)
+
+// Long algebraic move with an optional piece letter and promotion, "Ng1-f3",
+// "e7xd8=Q" or "e7e8q".
+var gLongMoveRegex = regexp.MustCompile(`^([KQRBN])?([a-h][1-8])[-x:]?([a-h][1-8])=?([QRBNqrbn])?$`)
+
+// SAN pawn move or castling, "e4", "exd5", "e8=Q" or "O-O".
+var gPawnMoveRegex = regexp.MustCompile(`^(([a-h]x)?[a-h][1-8](=?[QRBN])?|O-O(-O)?)$`)
+
+var gPieceTypes = map[byte]chess.PieceType{
+	'K': chess.King, 'Q': chess.Queen, 'R': chess.Rook, 'B': chess.Bishop, 'N': chess.Knight,
+}
+
+// Piece letters of other languages, in the order K Q R B N. French, Spanish
+// and Italian write R for the king, they are tried after English only.
+var gSANLocales = map[string]string{
+	"de": "KDTLS", "nl": "KDTLP", "fr": "RDTFC", "es": "RDTAC", "it": "RDTAC",
+}
+
+// Figurines of both colors and castling with zeros as English SAN.
+var gFigurineReplacer = strings.NewReplacer(
+	"♔", "K", "♕", "Q", "♖", "R", "♗", "B", "♘", "N", "♙", "",
+	"♚", "K", "♛", "Q", "♜", "R", "♝", "B", "♞", "N", "♟", "",
+	"0-0-0", "O-O-O", "0-0", "O-O",
+)
+
+// Castling with zeros, "0-0" or "0-0-0", in PGN movetext.
+var gZeroCastlingRegex = regexp.MustCompile(`\b0-0(-0)?\b`)
+
+// En passant marks, "exd6 e.p.", in PGN movetext.
+var gEnPassantRegex = regexp.MustCompile(`\s*e\.p\.`)
+
+// SAN of a valid move. Should the chess package fail on a move it generated
+// itself, the move is written in coordinates and the position printed for a
+// bug report, rather than bringing the game down.
+func safeSAN(pos *chess.Position, move *chess.Move) (san string) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println(gConsole.Faint(fmt.Sprintf("Unable to write %s in SAN (%v) at %s", move, r, pos)))
+			san = move.String()
+		}
+	}()
+	return chess.Encoder.Encode(chess.AlgebraicNotation{}, pos, move)
+}
+
+// The chess package's move, played by applyMove. Tests stand in a failing one.
+var gPlayMove = (*chess.Game).Move
+
+// Play a valid move. A failure of the chess package comes back as an error
+// with the position, so the player is asked again instead of the game crashing.
+// The game is left as it was.
+func applyMove(game *chess.Game, move *chess.Move) (err error) {
+	fen := game.Position().String()
+	before := game.Clone()
+	defer func() {
+		if r := recover(); r != nil {
+			*game = *before // The chess package records the move before playing it.
+			err = fmt.Errorf("unable to play %s: %v", move, r)
+		}
+		if err != nil {
+			err = fmt.Errorf("%v at %s", err, fen)
+		}
+	}()
+	return gPlayMove(game, move)
+}
+
+// SAN of the move in the style of `--castling` and `--en-passant`, for the
+// moves shown and saved. Some tools only read "0-0" or want "e.p.".
+func encodeSAN(pos *chess.Position, move *chess.Move) string {
+	san := safeSAN(pos, move)
+	if gCastling == "0-0" && move.HasTag(chess.KingSideCastle|chess.QueenSideCastle) {
+		san = strings.Replace(san, "O", "0", -1)
+	}
+	if gEnPassant != "" && move.HasTag(chess.EnPassant) {
+		san += " " + gEnPassant
+	}
+	return san
+}
+
+// Check and checkmate marks of the moves shown, `--check-marks`.
+func checkMarks() (check, mate string) {
+	marks := strings.SplitN(gCheckMarks, ",", 2)
+	return marks[0], marks[1]
+}
+
+// The move shown with the `--check-marks` in place of SAN's "+" and "#".
+func markCheck(san string) string {
+	check, mate := checkMarks()
+	if strings.Contains(san, "#") {
+		return strings.Replace(san, "#", mate, 1)
+	}
+	return strings.Replace(san, "+", check, 1)
+}
+
+// Movetext in the standard notation the chess package reads, whatever the
+// castling and en passant style it was written in. Tag pairs are kept as is.
+func standardMovetext(pgn string) string {
+	lines := strings.Split(pgn, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "[") {
+			continue
+		}
+		line = gZeroCastlingRegex.ReplaceAllStringFunc(line, func(castling string) string {
+			return strings.Replace(castling, "0", "O", -1)
+		})
+		lines[i] = gEnPassantRegex.ReplaceAllString(line, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Translate the piece letters of the language, e.g. German "Sf3" to "Nf3" or
+// "e8=D" to "e8=Q".
+func localizedSAN(moveStr, letters string) string {
+	san := []byte(moveStr)
+	for i, c := range san {
+		if i > 0 && san[i-1] != '=' && i != len(strings.TrimRight(moveStr, "+#!?"))-1 { // Only the piece and the promotion.
+			continue
+		}
+		if j := strings.IndexByte(letters, c); j >= 0 {
+			san[i] = "KQRBN"[j]
+		}
+	}
+	return string(san)
+}
+
+// The move matches more than one piece.
+type ambiguousMoveError struct {
+	move       string
+	candidates []string // SAN of the matching moves.
+}
+
+func (e *ambiguousMoveError) Error() string {
+	return e.move + " is ambiguous"
+}
+
+// The move would be legal for the other side: the piece is the opponent's.
+type opponentPieceError struct {
+	move string
+}
+
+func (e *opponentPieceError) Error() string {
+	return e.move + " moves an opponent's piece"
+}
+
+// The move does not get the king out of check.
+type inCheckError struct {
+	move string
+}
+
+func (e *inCheckError) Error() string {
+	return e.move + " leaves the king in check"
+}
+
+// Decode the human's move in SAN, long algebraic like "Ng1-f3" or coordinates
+// like "g1f3", whichever reads as a legal move. SAN may have figurines or the
+// piece letters of `--san-locales`. Only a move that reads differently in two
+// notations is ambiguous.
+func decodeMove(game *chess.Game, moveStr string) (*chess.Move, error) {
+	pos := game.Position()
+	moveStr = gEnPassantRegex.ReplaceAllString(gFigurineReplacer.Replace(moveStr), "")
+	var found []*chess.Move
+	if move := decodeSAN(pos, moveStr); move != nil {
+		found = append(found, move)
+	}
+	if move := decodeLongMove(pos, moveStr); move != nil && (len(found) == 0 || found[0].String() != move.String()) {
+		found = append(found, move)
+	}
+
+	// Piece moves with too little or too much disambiguation.
+	if len(found) == 0 {
+		found = pieceMoves(pos, moveStr)
+	}
+	switch len(found) {
+	case 0:
+		if opponentsMove(pos, moveStr) {
+			return nil, &opponentPieceError{move: moveStr}
+		}
+		if inCheck(pos) && readsAsMove(moveStr) {
+			return nil, &inCheckError{move: moveStr}
+		}
+		return nil, errors.New("illegal move " + moveStr)
+	case 1:
+		return found[0], nil
+	}
+
+	amb := &ambiguousMoveError{move: moveStr}
+	for _, move := range found {
+		amb.candidates = append(amb.candidates, encodeSAN(pos, move))
+	}
+	return nil, amb
+}
+
+// Does the move read as one of the other side's, were it their turn?
+func opponentsMove(pos *chess.Position, moveStr string) bool {
+	other := withTurn(pos, pos.Turn().Other())
+	if other == nil {
+		return false
+	}
+	return decodeSAN(other, moveStr) != nil || decodeLongMove(other, moveStr) != nil
+}
+
+// Does the text read as a move in one of the notations, legal or not? Not
+// gibberish like "xyz" or "Nz9".
+func readsAsMove(moveStr string) bool {
+	moveStr = strings.TrimRight(moveStr, "+#!?")
+	candidates := []string{moveStr, strings.ToLower(moveStr)}
+	for _, locale := range gLocales {
+		candidates = append(candidates, localizedSAN(moveStr, gSANLocales[locale]))
+	}
+	for _, move := range candidates {
+		if gPieceMoveRegex.MatchString(move) || gPawnMoveRegex.MatchString(move) || gLongMoveRegex.MatchString(move) {
+			return true
+		}
+	}
+	return false
+}
+
+// The move in English SAN, or else with the piece letters of `--san-locales`.
+func decodeSAN(pos *chess.Position, moveStr string) *chess.Move {
+	if move, err := (chess.AlgebraicNotation{}).Decode(pos, moveStr); err == nil {
+		return move
+	}
+	for _, locale := range gLocales {
+		if move, err := (chess.AlgebraicNotation{}).Decode(pos, localizedSAN(moveStr, gSANLocales[locale])); err == nil {
+			return move
+		}
+	}
+	return nil
+}
+
+// The move in long algebraic notation, "Ng1-f3", "e4xd5" or "e7-e8=Q", or in
+// coordinates like "g1f3" or "e7e8q". Castling is the king's move, "e1g1".
+func decodeLongMove(pos *chess.Position, moveStr string) *chess.Move {
+	moveStr = strings.TrimRight(moveStr, "+#!?")
+	m := gLongMoveRegex.FindStringSubmatch(moveStr)
+	if m == nil { // Coordinates in capitals, "E2E4".
+		m = gLongMoveRegex.FindStringSubmatch(strings.ToLower(moveStr))
+	}
+	if m == nil {
+		return nil
+	}
+	for _, move := range pos.ValidMoves() {
+		if move.S1().String() != m[2] || move.S2().String() != m[3] {
+			continue
+		}
+		if m[1] != "" && pos.Board().Piece(move.S1()).Type() != gPieceTypes[m[1][0]] {
+			continue
+		}
+		if (m[4] == "" && move.Promo() != chess.NoPieceType) || (m[4] != "" && move.Promo().String() != strings.ToLower(m[4])) {
+			continue
+		}
+		return move
+	}
+	return nil
+}
+
+// Valid moves of the piece to the square, matching the file or rank given.
+func pieceMoves(pos *chess.Position, moveStr string) []*chess.Move {
+	m := gPieceMoveRegex.FindStringSubmatch(strings.TrimRight(moveStr, "+#!?"))
+	if m == nil {
+		return nil
+	}
+	to, err := parseSquare(m[4])
+	if err != nil {
+		return nil
+	}
+
+	var candidates []*chess.Move
+	for _, move := range pos.ValidMoves() {
+		from := move.S1()
+		if move.S2() != to || pos.Board().Piece(from).Type() != gPieceTypes[m[1][0]] {
+			continue
+		}
+		if (m[2] != "" && from.File().String() != m[2]) || (m[3] != "" && from.Rank().String() != m[3]) {
+			continue
+		}
+		candidates = append(candidates, move)
+	}
+	return candidates
+}
+
+// Explain why the move was not played.
+func printMoveError(game *chess.Game, err error) {
+	if amb, ok := err.(*ambiguousMoveError); ok {
+		fmt.Println(gConsole.Bold(gConsole.Red(amb.move)).String()+" is ambiguous, did you mean",
+			gConsole.Bold(gConsole.Yellow(strings.Join(amb.candidates, " or "))).String()+"?")
+		return
+	}
+	if check, ok := err.(*inCheckError); ok {
+		fmt.Println("You're in check,", gConsole.Bold(gConsole.Red(check.move)).String(), "does not get out of it.")
+		fmt.Println("Moves out of check:", gConsole.Bold(gConsole.Yellow(validMoves(game))))
+		return
+	}
+	if opp, ok := err.(*opponentPieceError); ok {
+		fmt.Println(gConsole.Bold(gConsole.Red(opp.move)).String() + ": that's your opponent's piece.")
+	}
+	fmt.Println("Allowed moves:", gConsole.Bold(gConsole.Yellow(validMoves(game))))
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+)
+
+func TestSafeSAN(t *testing.T) {
+	game := chess.NewGame()
+	move := game.ValidMoves()[0]
+	if san := safeSAN(game.Position(), move); san == move.String() {
+		t.Errorf("safeSAN(%v) = %q, want SAN", move, san)
+	}
+	// A position without a board fails the encoder.
+	if san := safeSAN(&chess.Position{}, move); san != move.String() {
+		t.Errorf("safeSAN on a broken position = %q, want coordinates %q", san, move.String())
+	}
+}
+
+func TestApplyMove(t *testing.T) {
+	defer func(old func(*chess.Game, *chess.Move) error) { gPlayMove = old }(gPlayMove)
+	game := chess.NewGame()
+	if err := applyMove(game, game.ValidMoves()[0]); err != nil {
+		t.Fatalf("applyMove: %v", err)
+	}
+
+	// The chess package records the move, then fails on it.
+	gPlayMove = func(game *chess.Game, move *chess.Move) error {
+		game.Move(move)
+		panic(errors.New("corrupt position"))
+	}
+	fen := game.Position().String()
+	move := game.ValidMoves()[0]
+	err := applyMove(game, move)
+	if err == nil {
+		t.Fatalf("applyMove(%v) hid the failure", move)
+	}
+	if !strings.Contains(err.Error(), "corrupt position") || !strings.Contains(err.Error(), fen) {
+		t.Errorf("applyMove error %q does not name the failure and the position %s", err, fen)
+	}
+	if got := len(game.Moves()); got != 1 {
+		t.Errorf("game has %d moves after the failure, want 1", got)
+	}
+	if got := game.Position().String(); got != fen {
+		t.Errorf("position after the failure = %s, want %s", got, fen)
+	}
+}
+
+func TestDecodeMoveEnPassant(t *testing.T) {
+	defer func(old string) { gEnPassant = old }(gEnPassant)
+	game := testGame(t, "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3")
+
+	for _, moveStr := range []string{"exf6", "exf6 e.p.", "exf6e.p.", "e5f6 e.p."} {
+		move, err := decodeMove(game, moveStr)
+		if err != nil {
+			t.Errorf("decodeMove(%q): %v", moveStr, err)
+			continue
+		}
+		if move.String() != "e5f6" || !move.HasTag(chess.EnPassant) {
+			t.Errorf("decodeMove(%q) = %v, want e5f6 en passant", moveStr, move)
+		}
+	}
+
+	// The move as shown with `--en-passant` reads back.
+	gEnPassant = "e.p."
+	move, _ := decodeMove(game, "exf6")
+	san := encodeSAN(game.Position(), move)
+	if san != "exf6 e.p." {
+		t.Fatalf("encodeSAN = %q, want %q", san, "exf6 e.p.")
+	}
+	if back, err := decodeMove(game, san); err != nil || back.String() != move.String() {
+		t.Errorf("decodeMove(%q) = %v, %v, want %v", san, back, err, move)
+	}
+}
+
+// In check, a move that does not get out of it is told apart from gibberish.
+func TestDecodeMoveInCheck(t *testing.T) {
+	game := testGame(t, "rnb1kbnr/pppp1ppp/8/4p3/7q/5P2/PPPPP1PP/RNBQKBNR w KQkq - 1 3")
+	tests := []struct {
+		moveStr string
+		inCheck bool
+	}{
+		{"a3", true},
+		{"Nc3", true},
+		{"O-O", true},
+		{"b1c3", true},
+		{"Ng1-h3", true},
+		{"xyz", false},
+		{"Nz9", false},
+		{"e9", false},
+	}
+	for _, tt := range tests {
+		_, err := decodeMove(game, tt.moveStr)
+		if err == nil {
+			t.Errorf("decodeMove(%q) played a move in check", tt.moveStr)
+			continue
+		}
+		if _, ok := err.(*inCheckError); ok != tt.inCheck {
+			t.Errorf("decodeMove(%q) = %v, in check error %v, want %v", tt.moveStr, err, ok, tt.inCheck)
+		}
+	}
+	if move, err := decodeMove(game, "g3"); err != nil || move.String() != "g2g3" {
+		t.Errorf("decodeMove(g3) = %v, %v, want g2g3", move, err)
+	}
+}
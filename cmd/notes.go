@@ -0,0 +1,109 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Teaching notes printed when an instructive position comes up, `--teach`.
+// They are keyed by the first three FEN fields. Tools disagree on when to set
+// the en passant square, and the move counters do not matter.
+var (
+	gNotes         map[string]string
+	gNotedPosition string // Not to repeat the note on every redraw.
+)
+
+// Notes at hand without a `--notes` file.
+var gBundledNotes = []struct {
+	fen  string
+	note string
+}{
+	{"rnbqkbnr/pppp1ppp/8/4p2Q/4P3/8/PPPP1PPP/RNB1KBNR b KQkq -",
+		"The queen comes out early, eyeing e5 and f7. Defend with ...Nc6, then gain time chasing her with your pieces."},
+	{"r1bqkb1r/pppp1ppp/2n2n2/4p1N1/2B1P3/8/PPPP1PPP/RNBQK2R b KQkq -",
+		"The knight and bishop both hit f7. ...d5 blocks the bishop, the wild ...Bc5 counterattacks f2 instead."},
+	{"r1bqkb1r/ppp2ppp/2n5/3np1N1/2B5/8/PPPP1PPP/RNBQK2R w KQkq -",
+		"The knight on d5 is pinned to f7 in all but name. Nxf7 drags the king out, the Fried Liver, d4 opens the center more soundly."},
+	{"r1bqk1nr/pppp1ppp/2n5/2b1N3/2B1P3/8/PPPP1PPP/RNBQK2R b KQkq -",
+		"Grabbing e5 loses a piece. After ...Nxe5 d4 Bxd4 Qxd4, ...Qf6 holds everything together."},
+	{"r1bqkbnr/1pp2ppp/p1p5/4N3/4P3/8/PPPP1PPP/RNBQK2R b KQkq -",
+		"e5 was not really hanging. ...Qd4 forks the knight and e4, and wins the pawn back."},
+	{"r1bqkb1r/pppn1ppp/5n2/3N2B1/3P4/8/PP2PPPP/R2QKBNR b KQkq -",
+		"The Elephant Trap. ...Nxd5 gives up the queen, Bxd8 Bb4+ wins her back with a piece to spare."},
+}
+
+// Load the bundled notes and those of the file, if any. Each line of the file
+// is a FEN and the note after a ';', '#' starts a comment line.
+func loadNotes(path string) (map[string]string, error) {
+	notes := map[string]string{}
+	for _, n := range gBundledNotes {
+		notes[noteKey(n.fen)] = n.note
+	}
+	if path == "" {
+		return notes, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ";", 2)
+		if len(fields) != 2 || strings.TrimSpace(fields[1]) == "" {
+			return nil, fmt.Errorf("line %d: expected a FEN, ';' and the note", n)
+		}
+		key := noteKey(fields[0])
+		if _, err := chess.FEN(key + " - 0 1"); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		notes[key] = strings.TrimSpace(fields[1])
+	}
+	return notes, scanner.Err()
+}
+
+// Placement, turn and castling, the FEN fields a position is known by.
+func noteKey(fen string) string {
+	fields := strings.Fields(fen)
+	if len(fields) > 3 {
+		fields = fields[:3]
+	}
+	return strings.Join(fields, " ")
+}
+
+// Note on the game's position, once, or "" if there is none.
+func teachingNote(game *chess.Game) string {
+	key := noteKey(game.FEN())
+	note, ok := gNotes[key]
+	if !ok || key == gNotedPosition {
+		return ""
+	}
+	gNotedPosition = key
+	return gConsole.Bold(gConsole.Cyan("Note:")).String() + " " + note + "\n"
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/spf13/cobra"
+)
+
+// Named starting positions for `--start`. The config file adds its own as
+// `start.<name> = "FEN"`, or replaces these.
+var gStartPositions = map[string]string{
+	"italian-game":         "r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R b KQkq - 3 3", // 1.e4 e5 2.Nf3 Nc6 3.Bc4
+	"ruy-lopez":            "r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R b KQkq - 3 3", // 1.e4 e5 2.Nf3 Nc6 3.Bb5
+	"scotch-game":          "r1bqkbnr/pppp1ppp/2n5/4p3/3PP3/5N2/PPP2PPP/RNBQKB1R b KQkq d3 0 3", // 1.e4 e5 2.Nf3 Nc6 3.d4
+	"kings-gambit":         "rnbqkbnr/pppp1ppp/8/4p3/4PP2/8/PPPP2PP/RNBQKBNR b KQkq f3 0 2",     // 1.e4 e5 2.f4
+	"sicilian-defense":     "rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2",     // 1.e4 c5
+	"french-defense":       "rnbqkbnr/pppp1ppp/4p3/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",      // 1.e4 e6
+	"caro-kann-defense":    "rnbqkbnr/pp1ppppp/2p5/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",      // 1.e4 c6
+	"queens-gambit":        "rnbqkbnr/ppp1pppp/8/3p4/2PP4/8/PP2PPPP/RNBQKBNR b KQkq c3 0 2",     // 1.d4 d5 2.c4
+	"slav-defense":         "rnbqkbnr/pp2pppp/2p5/3p4/2PP4/8/PP2PPPP/RNBQKBNR w KQkq - 0 3",     // 1.d4 d5 2.c4 c6
+	"kings-indian-defense": "rnbqkb1r/pppppp1p/5np1/8/2PP4/8/PP2PPPP/RNBQKBNR w KQkq - 0 3",     // 1.d4 Nf6 2.c4 g6
+	"london-system":        "rnbqkbnr/ppp1pppp/8/3p4/3P1B2/8/PPP1PPPP/RN1QKBNR b KQkq - 1 2",    // 1.d4 d5 2.Bf4
+	"english-opening":      "rnbqkbnr/pppppppp/8/8/2P5/8/PP1PPPPP/RNBQKBNR b KQkq c3 0 1",       // 1.c4
+}
+
+// Add a `start.<name>` position of the config file.
+func setStartPosition(name, fen string) error {
+	if name == "" {
+		return fmt.Errorf("start.%s: expected start.<name>", name)
+	}
+	if _, err := chess.FEN(sanitizeFEN(fen)); err != nil {
+		return fmt.Errorf("invalid FEN for start.%s", name)
+	}
+	gStartPositions[name] = fen
+	return nil
+}
+
+// Start from the `--start` position, in place of a FEN.
+func useStartPosition(cmd *cobra.Command) {
+	if gStartName == "" {
+		return
+	}
+	if cmd.Flags().Changed("fen") {
+		fmt.Println("Use either --fen or --start, not both.")
+		os.Exit(1)
+	}
+	fen, ok := gStartPositions[strings.ToLower(gStartName)]
+	if !ok {
+		fmt.Println("Invalid --start value " + strconv.Quote(gStartName) + ". See `pinata positions` for the names.")
+		os.Exit(1)
+	}
+	gStartFEN = fen
+}
+
+// Names of the starting positions, sorted.
+func startPositionNames() []string {
+	names := make([]string, 0, len(gStartPositions))
+	for name := range gStartPositions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// positionsCmd lists the named starting positions.
+var positionsCmd = &cobra.Command{
+	Use:     "positions",
+	Short:   "List the named starting positions of --start",
+	Example: `  pinata positions`,
+	Args:    cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		for _, name := range startPositionNames() {
+			fmt.Println(gConsole.Bold(gConsole.Yellow(name)).String() + "  " + gStartPositions[name])
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(positionsCmd)
+}
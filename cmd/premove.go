@@ -0,0 +1,118 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/abperiasamy/chess"
+	"github.com/chzyer/readline"
+)
+
+// Engine's reply searched in the background with `--premove`, while the human
+// types the next move.
+type pendingReply struct {
+	mu   sync.Mutex
+	done bool // The engine has moved and the human is to move again.
+	err  chan error
+	game *chess.Game // The engine's own copy of the game, with its reply played.
+	move *chess.Move // The reply, nil if the engine did not move.
+}
+
+// Let the engine reply in the background. The reply is printed above the input
+// line and the prompt turns back to the human's. The engine searches its own
+// copy of the game, the shell and its completer keep reading the game until
+// wait plays the reply in it.
+func engineReplyAsync(l *readline.Instance, engine *uciEngine, game *chess.Game) *pendingReply {
+	reply := &pendingReply{err: make(chan error, 1), game: rewindGame(game, len(game.Moves()))}
+	go func() {
+		search := reply.game
+		move, err := engineReply(engine, search)
+
+		reply.mu.Lock()
+		if err == nil && move != nil {
+			out := enginePrompt() + showMove(search, move) + "\n" + gSearchNote
+			if err = applyMove(search, move); err != nil {
+				out += err.Error() + "\n"
+			} else {
+				reply.move = move
+				out += boardView(search)
+			}
+			l.Stdout().Write([]byte(out))
+		}
+		if gClock != nil && search.Outcome() == chess.NoOutcome { // Human's clock is ticking.
+			gClock.Start(humanColor())
+		}
+		reply.done = true
+		l.SetPrompt(humanPrompt(search))
+		reply.mu.Unlock()
+
+		l.Refresh()
+		reply.err <- err
+	}()
+	return reply
+}
+
+// Prompt for the premove while the engine thinks. The move count is left for
+// the human's prompt after the reply.
+func (r *pendingReply) setPrompt(l *readline.Instance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.done {
+		l.SetPrompt(gConsole.Faint("premove").String() + " ")
+	}
+}
+
+// Check whether the engine has replied yet.
+func (r *pendingReply) replied() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.done
+}
+
+// Wait for the engine's reply and play it in the game.
+func (r *pendingReply) wait(game *chess.Game) error {
+	err := <-r.err
+	switch {
+	case r.move != nil:
+		if err := applyMove(game, r.move); err != nil {
+			return err
+		}
+		mirrorFEN(game)
+		gSessionLog.moves(game)
+	case r.game.Outcome() != chess.NoOutcome: // The engine's flag fell, flagFall told.
+		game.Resign(game.Position().Turn())
+		game.AddTagPair("Termination", "time forfeit")
+	}
+	return err
+}
+
+// Play the premove if it is legal after the engine's reply, otherwise drop it.
+// Returns the move to play, "" if discarded.
+func checkPremove(game *chess.Game, moveStr string) string {
+	if game.Outcome() != chess.NoOutcome {
+		fmt.Println("Premove", gConsole.Bold(gConsole.Yellow(moveStr)), "discarded, the game is over.")
+		return ""
+	}
+	if _, err := decodeMove(game, moveStr); err != nil {
+		fmt.Println("Premove", gConsole.Bold(gConsole.Yellow(moveStr)), "discarded, it is not legal after the engine's reply.")
+		return ""
+	}
+	fmt.Println("Premove", gConsole.Bold(gConsole.Yellow(moveStr)))
+	return moveStr
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// A named set of engine settings from the config file, picked with
+// `--profile`:
+//
+//	profile.strong.engine = "stockfish"
+//	profile.strong.hash = 512
+//	profile.strong.nickname = "Stockfish at full strength"
+//	profile.strong.option.Move Overhead = 100
+//
+// Keys are flags, the engine's name in saved games, or UCI options the flags
+// do not cover.
+type engineProfile struct {
+	nickname string
+	flags    [][2]string       // Flag settings in the order given.
+	options  map[string]string // UCI options by name.
+}
+
+var gProfiles = map[string]*engineProfile{}
+
+// Flags set by the config file, as opposed to the command-line.
+var gConfigFlags = map[string]bool{}
+
+// Add a `profile.<name>.<key>` setting of the config file.
+func setProfile(cmd *cobra.Command, setting, value string) error {
+	parts := strings.SplitN(setting, ".", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("profile.%s: expected profile.<name>.<key>", setting)
+	}
+	name, key := parts[0], parts[1]
+	profile, ok := gProfiles[name]
+	if !ok {
+		profile = &engineProfile{options: map[string]string{}}
+		gProfiles[name] = profile
+	}
+
+	switch {
+	case key == "nickname":
+		profile.nickname = value
+	case strings.HasPrefix(key, "option."):
+		profile.options[strings.TrimPrefix(key, "option.")] = value
+	case key == "config" || key == "profile" || cmd.Flags().Lookup(key) == nil:
+		return fmt.Errorf("unknown profile key %s", key)
+	default:
+		profile.flags = append(profile.flags, [2]string{key, value})
+	}
+	return nil
+}
+
+// Apply the `--profile` settings over the config file's. Command-line flags
+// still override them.
+func useProfile(cmd *cobra.Command) {
+	if gProfile == "" {
+		return
+	}
+	profile, ok := gProfiles[gProfile]
+	if !ok {
+		fmt.Println("Invalid --profile value " + strconv.Quote(gProfile) + ". Allowed values are [" + strings.Join(profileNames(), "|") + "].")
+		os.Exit(1)
+	}
+	for _, setting := range profile.flags {
+		key, value := setting[0], setting[1]
+		if cmd.Flags().Changed(key) && !gConfigFlags[key] {
+			continue
+		}
+		if err := cmd.Flags().Set(key, value); err != nil {
+			fmt.Printf("Invalid value %q for %s in profile %s.\n", value, key, gProfile)
+			os.Exit(1)
+		}
+	}
+	gEngineNickname = profile.nickname
+	gEngineOptions = profile.options
+}
+
+// Send the profile's UCI options the engine knows of.
+func setProfileOptions(eng *uciEngine) {
+	for name, value := range gEngineOptions {
+		opt, ok := eng.Options[strings.ToLower(name)]
+		if !ok {
+			fmt.Println(gConsole.Faint("The engine has no option " + name + ", ignored."))
+			continue
+		}
+		eng.SendOption(opt.Name, value)
+	}
+}
+
+// Profile names, sorted.
+func profileNames() []string {
+	names := make([]string, 0, len(gProfiles))
+	for name := range gProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profilesCmd lists the engine profiles of the config file.
+var profilesCmd = &cobra.Command{
+	Use:     "profiles",
+	Short:   "List the engine profiles of the config file",
+	Example: `  pinata profiles`,
+	Args:    cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		if len(gProfiles) == 0 {
+			fmt.Println("No profiles in", gCfgFile+". Add them as profile.<name>.<key> = value.")
+			return
+		}
+		for _, name := range profileNames() {
+			profile := gProfiles[name]
+			title := gConsole.Bold(gConsole.Yellow(name)).String()
+			if profile.nickname != "" {
+				title += " (" + profile.nickname + ")"
+			}
+			fmt.Println(title)
+			for _, setting := range profile.flags {
+				fmt.Println("  --"+setting[0], setting[1])
+			}
+			options := make([]string, 0, len(profile.options))
+			for option := range profile.options {
+				options = append(options, option)
+			}
+			sort.Strings(options)
+			for _, option := range options {
+				fmt.Println("  option", option+" = "+profile.options[option])
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(profilesCmd)
+}
@@ -17,14 +17,33 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
 )
 
 const (
 	gWhitePrompt string = "█"
 	gBlackPrompt string = "░"
+
+	gDefaultPrompt string = "{turn} {move} {clock} {player}" // Config key `prompt`.
 )
 
+// Tokens of the prompt template.
+var gPromptTokenRegex = regexp.MustCompile(`\{(\w+)\}`)
+
+// Continue the move count from the game's position, e.g. after loading a game.
+func syncMoveCount(game *chess.Game) {
+	fields := strings.Fields(game.FEN()) // The last FEN field is the full move number.
+	if n, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+		gMoveCount = n
+	}
+}
+
 // White prompt
 func whitePrompt() string {
 	// ASCII prompt
@@ -70,17 +89,84 @@ func enginePrompt() string {
 	}
 }
 
-// Human's shell prompt
-func humanPrompt() string {
+// Human's shell prompt from the prompt template, in the game's position.
+func humanPrompt(game *chess.Game) string {
+	if gSandbox != nil { // Both sides move in the sandbox.
+		syncMoveCount(game)
+		mode := "sandbox"
+		if gAnalysis != nil {
+			mode = "analysis"
+		}
+		return gConsole.Bold(gConsole.Magenta(mode)).String() + " " + expandPrompt(gPromptTemplate, promptTokens(game)) + " "
+	}
+	if gTwoPlayer {
+		syncMoveCount(game)
+		return expandPrompt(gPromptTemplate, promptTokens(game)) + " "
+	}
+
+	if gHumanIsBlack {
+		defer func() { gMoveCount += 1 }() // Count the nth move.
+	}
+	return expandPrompt(gPromptTemplate, promptTokens(game)) + " "
+}
+
+// Check the prompt template for unknown tokens.
+func validatePrompt(template string) error {
+	tokens := promptTokens(nil)
+	for _, match := range gPromptTokenRegex.FindAllStringSubmatch(template, -1) {
+		if _, ok := tokens[match[1]]; !ok {
+			return fmt.Errorf("unknown prompt token %s", match[0])
+		}
+	}
+	return nil
+}
+
+// Expand the template tokens. Empty tokens drop their surrounding space.
+func expandPrompt(template string, tokens map[string]string) string {
+	prompt := gPromptTokenRegex.ReplaceAllStringFunc(template, func(token string) string {
+		return tokens[token[1:len(token)-1]]
+	})
+	return strings.Join(strings.Fields(prompt), " ")
+}
+
+// Values of the prompt template tokens in the game's position, the defaults
+// without a game.
+func promptTokens(game *chess.Game) map[string]string {
+	tokens := map[string]string{
+		"turn":   gWhitePrompt,
+		"move":   strconv.Itoa(gMoveCount),
+		"check":  "",
+		"clock":  "",
+		"player": "🙇",
+	}
+
+	turn := chess.White
+	if game != nil {
+		turn = game.Position().Turn()
+	}
+	if (turn == chess.Black) != gLightBg { // Invert on light background.
+		tokens["turn"] = gBlackPrompt
+	}
 	if gNoColor {
-		if gHumanIsBlack {
-			return blackPrompt() + ":) "
+		tokens["turn"] = "W"
+		if turn == chess.Black {
+			tokens["turn"] = "B"
 		}
-		return whitePrompt() + ":) "
-	} else {
-		if gHumanIsBlack {
-			return blackPrompt() + "🙇 "
+		tokens["player"] = ":)"
+	}
+
+	if game != nil {
+		if moves := game.Moves(); len(moves) > 0 && moves[len(moves)-1].HasTag(chess.Check) {
+			tokens["check"] = gConsole.Bold(gConsole.Red("+")).String()
+		}
+	}
+	if gClock != nil {
+		tokens["clock"] = gClock.String(humanColor())
+		if gClock.Paused() {
+			tokens["clock"] = gConsole.Bold(gConsole.Yellow(tokens["clock"] + " paused")).String()
+		} else if gClock.Remaining(humanColor()) < 10*time.Second { // Time trouble.
+			tokens["clock"] = gConsole.Bold(gConsole.Red(tokens["clock"])).String()
 		}
-		return whitePrompt() + "🙇 "
 	}
+	return tokens
 }
@@ -0,0 +1,226 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gPuzzleDaily  bool
+	gPuzzleSource string
+)
+
+// A position to solve. The solution is in UCI notation and starts with the
+// solver's move, the opponent's replies in between.
+type puzzle struct {
+	id       string
+	fen      string
+	solution []string
+}
+
+// Puzzles at hand when offline, all mates.
+var gBundledPuzzles = []puzzle{
+	{"back-rank", "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1", []string{"d1d8"}},
+	{"scholars-mate", "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", []string{"h5f7"}},
+	{"smothered", "6rk/6pp/8/6N1/8/8/8/6K1 w - - 0 1", []string{"g5f7"}},
+	{"philidors-legacy", "3r3k/6pp/7N/8/8/1Q6/8/6K1 w - - 0 1", []string{"b3g8", "d8g8", "h6f7"}},
+	{"fools-mate", "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq g3 0 2", []string{"d8h4"}},
+	{"arabian", "7k/R7/5N2/8/8/8/8/6K1 w - - 0 1", []string{"a7h7"}},
+}
+
+// puzzleCmd drops into puzzle solving.
+var puzzleCmd = &cobra.Command{
+	Use:   "puzzle",
+	Short: "Solve a puzzle, the daily one or one from the bundled set",
+	Long: `Solve a puzzle, the daily one from --source or a random one from the bundled
+set. The source serves puzzles in the lichess.org format. Without a network the
+bundled set stands in. /solution shows the solution, /quit gives up.`,
+	Example: `  pinata puzzle --daily`,
+	Args:    cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		p := gBundledPuzzles[newRand().Intn(len(gBundledPuzzles))]
+		if gPuzzleDaily {
+			daily, err := fetchPuzzle(gPuzzleSource)
+			if err != nil {
+				fmt.Println("Unable to fetch the daily puzzle,", err)
+				fmt.Println("Here is one from the bundled set.")
+			} else {
+				p = daily
+			}
+		}
+
+		l, err := readline.New("")
+		if err != nil {
+			panic(err)
+		}
+		defer l.Close()
+
+		solvePuzzle(l, p)
+	},
+}
+
+// Fetch a puzzle in the lichess.org format: the game's SAN moves up to the
+// puzzle and the solution in UCI.
+func fetchPuzzle(source string) (puzzle, error) {
+	req, err := http.NewRequest(http.MethodGet, source, nil)
+	if err != nil {
+		return puzzle{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Piñata "+gVersion)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return puzzle{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return puzzle{}, fmt.Errorf("%s answered %s", source, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return puzzle{}, err
+	}
+
+	daily := struct {
+		Game struct {
+			PGN string
+		}
+		Puzzle struct {
+			ID       string
+			Solution []string
+		}
+	}{}
+	if err = json.Unmarshal(body, &daily); err != nil {
+		return puzzle{}, err
+	}
+	if len(daily.Puzzle.Solution) == 0 {
+		return puzzle{}, fmt.Errorf("no puzzle from %s", source)
+	}
+
+	// The puzzle starts after the game's moves.
+	game := chess.NewGame()
+	for _, san := range strings.Fields(daily.Game.PGN) {
+		if gMoveNumberRegex.MatchString(san) { // Move numbers are optional.
+			continue
+		}
+		move, err := chess.AlgebraicNotation{}.Decode(game.Position(), san)
+		if err != nil {
+			return puzzle{}, fmt.Errorf("puzzle game has an invalid move %s", san)
+		}
+		game.Move(move)
+	}
+	return puzzle{id: daily.Puzzle.ID, fen: game.FEN(), solution: daily.Puzzle.Solution}, nil
+}
+
+// Solve the puzzle move by move, the opponent's replies are played from the solution.
+// Any mate solves the puzzle too.
+func solvePuzzle(l *readline.Instance, p puzzle) {
+	fen, err := chess.FEN(p.fen)
+	if err != nil {
+		fmt.Println("Puzzle", p.id, "has an invalid FEN.")
+		return
+	}
+	game := chess.NewGame(fen, chess.UseNotation(chess.AlgebraicNotation{}))
+	gHumanIsBlack = game.Position().Turn() == chess.Black
+	gVisual = true // There is no game to follow blind.
+
+	fmt.Println("Puzzle", gConsole.Bold(gConsole.Yellow(p.id)).String()+":", humanColor().Name(), "to move.")
+	drawBoard(game)
+
+	for i := 0; i < len(p.solution); {
+		l.SetPrompt(moveNumber(game.Position()) + gConsole.Faint("?").String() + " ")
+		cmd, err := l.Readline()
+		if err == readline.ErrInterrupt || err == io.EOF {
+			cmd = "/quit"
+		}
+		cmd = strings.TrimSpace(cmd)
+		switch cmd {
+		case "":
+			continue
+		case "/quit":
+			return
+		case "/solution":
+			printSolution(game, p.solution[i:])
+			return
+		}
+
+		move, err := decodeMove(game, cmd)
+		if err != nil {
+			printMoveError(game, err)
+			continue
+		}
+		if move.String() != p.solution[i] {
+			if after := game.Clone(); after.Move(move) == nil && after.Method() == chess.Checkmate {
+				fmt.Println(gConsole.Bold(gConsole.Green("Checkmate, puzzle solved!")))
+				return
+			}
+			fmt.Println(gConsole.Bold(gConsole.Red(moveSAN(game, move))), "is not it, try again.")
+			continue
+		}
+		game.Move(move)
+		i++
+
+		if i < len(p.solution) { // The opponent's reply.
+			reply, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), p.solution[i])
+			if err != nil {
+				fmt.Println("Puzzle", p.id, "has an invalid solution.")
+				return
+			}
+			fmt.Println(moveNumber(game.Position()) + showMove(game, reply))
+			game.Move(reply)
+			i++
+		}
+		drawBoard(game)
+	}
+	fmt.Println(gConsole.Bold(gConsole.Green("Puzzle solved!")))
+}
+
+// Print the rest of the solution in SAN.
+func printSolution(game *chess.Game, solution []string) {
+	game = game.Clone()
+	var moves []string
+	for _, lan := range solution {
+		move, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), lan)
+		if err != nil {
+			break
+		}
+		moves = append(moves, moveNumber(game.Position())+showMove(game, move))
+		game.Move(move)
+	}
+	fmt.Println("Solution:", gConsole.Bold(gConsole.Yellow(strings.Join(moves, " "))))
+}
+
+func init() {
+	puzzleCmd.Flags().BoolVar(&gPuzzleDaily, "daily", false, "fetch the daily puzzle from the source")
+	puzzleCmd.Flags().StringVar(&gPuzzleSource, "source", "https://lichess.org/api/puzzle/daily", "puzzle source in the lichess.org format")
+	rootCmd.AddCommand(puzzleCmd)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+)
+
+var gQuizSide string
+
+// quizCmd plays guess-the-move through a saved game.
+var quizCmd = &cobra.Command{
+	Use:   "quiz FILE",
+	Short: "Guess the moves of a saved game, move by move",
+	Long: `Guess the moves of a saved game, move by move. A guess scores if it is the
+move played, or as good as the engine's best. An empty line passes, /quit
+ends the quiz early.`,
+	Example: `  pinata quiz morphy-opera.pgn --side white`,
+	Args:    cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		switch gQuizSide {
+		case "white", "black", "both":
+		default:
+			fmt.Println("Invalid --side value " + strconv.Quote(gQuizSide) + ". Allowed values are [white|black|both].")
+			os.Exit(1)
+		}
+
+		game := readPGN(nil, args[0])
+		if game == nil {
+			os.Exit(1)
+		}
+		if len(game.Moves()) == 0 {
+			fmt.Println(gConsole.Bold(gConsole.Red(args[0])), "has no moves to guess.")
+			os.Exit(1)
+		}
+		gHumanIsBlack = gQuizSide == "black" // Face the board from the guessing side.
+
+		engine, _ := newEngine(gEngineBinary)
+		defer engine.Close()
+		engine.IsReady()
+
+		l, err := readline.New("")
+		if err != nil {
+			panic(err)
+		}
+		defer l.Close()
+
+		quiz(l, engine, game)
+	},
+}
+
+// Guess the game's moves of the chosen side and print the score.
+func quiz(l *readline.Instance, engine *uciEngine, game *chess.Game) {
+	positions, moves := game.Positions(), game.Moves()
+	guessed, score := 0, 0
+
+	// Replay the game on a board of its own, it starts where the saved game does.
+	fen, _ := chess.FEN(positions[0].String())
+	board := chess.NewGame(fen, chess.UseNotation(chess.AlgebraicNotation{}))
+	drawBoard(board)
+
+	for i, played := range moves {
+		color := positions[i].Turn()
+		prefix := moveNumber(positions[i])
+		playedSAN := showMove(board, played)
+		if gQuizSide != "both" && (color == chess.Black) != (gQuizSide == "black") { // Not to guess, just play it.
+			fmt.Println(prefix + playedSAN)
+			board.Move(played)
+			drawBoard(board)
+			continue
+		}
+
+		l.SetPrompt(prefix + gConsole.Faint("?").String() + " ")
+		guess, err := l.Readline()
+		if err == readline.ErrInterrupt || err == io.EOF {
+			break
+		}
+		guess = strings.TrimSpace(guess)
+		if guess == "/quit" {
+			break
+		}
+
+		guessed++
+		if guess != "" {
+			if ok := quizGuess(engine, board, guess, played); ok {
+				score++
+			}
+		}
+		fmt.Println("The game went", gConsole.Bold(gConsole.Yellow(prefix+playedSAN)))
+		board.Move(played)
+		drawBoard(board)
+	}
+
+	if guessed == 0 {
+		return
+	}
+	fmt.Println("Score:", gConsole.Bold(gConsole.Yellow(score)).String(), "out of", guessed, "moves",
+		fmt.Sprintf("(%.0f%%)", 100*float64(score)/float64(guessed)))
+}
+
+// Check the guess against the move played, and the engine's good moves.
+func quizGuess(engine *uciEngine, board *chess.Game, guess string, played *chess.Move) bool {
+	move, err := decodeMove(board, guess)
+	if err != nil {
+		printMoveError(board, err)
+		return false
+	}
+	if move.String() == played.String() {
+		fmt.Println(gConsole.Bold(gConsole.Green("Correct!")))
+		return true
+	}
+
+	good, err := goodMoves(engine, board)
+	if err != nil {
+		fmt.Println("Unable to evaluate the guess,", err)
+		return false
+	}
+	for _, line := range good {
+		if line.PV[0] == move.String() {
+			fmt.Println(gConsole.Bold(gConsole.Green(moveSAN(board, move))), "is just as good,", formatScore(line)+".")
+			return true
+		}
+	}
+	fmt.Println(gConsole.Bold(gConsole.Red(moveSAN(board, move))), "is not as good.")
+	return false
+}
+
+// Move number of the position as written before a move, "12." or "12...".
+func moveNumber(pos *chess.Position) string {
+	fields := strings.Fields(pos.String()) // The last FEN field is the full move number.
+	if pos.Turn() == chess.Black {
+		return fields[len(fields)-1] + "... "
+	}
+	return fields[len(fields)-1] + ". "
+}
+
+func init() {
+	quizCmd.Flags().StringVar(&gQuizSide, "side", "both", "guess the moves of this side [white|black|both]")
+	rootCmd.AddCommand(quizCmd)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Built-in engine playing random legal moves, `--engine random`. It needs no
+// binary and speaks UCI like any other engine.
+const gRandomEngine = "Random"
+
+func isRandomEngine(path string) bool {
+	return strings.EqualFold(path, gRandomEngine)
+}
+
+// Source of the random choices, reproducible with `--seed`.
+func newRand() *rand.Rand {
+	seed := gSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// Random legal move, or none when the game is over.
+func randomMove(rnd *rand.Rand) builtinSearch {
+	return func(pos *chess.Position, params string) *chess.Move {
+		moves := pos.ValidMoves()
+		if len(moves) == 0 {
+			return nil
+		}
+		return moves[rnd.Intn(len(moves))]
+	}
+}
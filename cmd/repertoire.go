@@ -0,0 +1,236 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Move numbers like "1." or "12..." in a repertoire line.
+var gMoveNumberRegex = regexp.MustCompile(`^\d+\.+`)
+
+// Opening lines to drill. The repertoire file has one line of SAN moves per
+// line, e.g. "1.e4 e5 2.Nf3 Nc6 3.Bb5", and '#' starts a comment. The times
+// each line was played to the end are kept next to it in a ".drilled" file.
+type repertoire struct {
+	path    string
+	lines   [][]string
+	drilled map[string]int  // Keyed by the line's moves.
+	played  map[string]bool // Lines completed in this game.
+	warned  string          // Deviation refused once already.
+}
+
+// Load the repertoire and its drill counts.
+func loadRepertoire(path string) (*repertoire, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rep := &repertoire{path: path, drilled: map[string]int{}, played: map[string]bool{}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		var moves []string
+		for _, field := range strings.Fields(line) {
+			if move := plainSAN(gMoveNumberRegex.ReplaceAllString(field, "")); move != "" {
+				moves = append(moves, move)
+			}
+		}
+		if len(moves) > 0 {
+			rep.lines = append(rep.lines, moves)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(rep.lines) == 0 {
+		return nil, fmt.Errorf("no lines in %s", path)
+	}
+
+	// Drill counts are optional.
+	if dat, err := ioutil.ReadFile(path + ".drilled"); err == nil {
+		for _, line := range strings.Split(string(dat), "\n") {
+			fields := strings.SplitN(line, "\t", 2)
+			if n, err := strconv.Atoi(fields[0]); err == nil && len(fields) == 2 {
+				rep.drilled[fields[1]] = n
+			}
+		}
+	}
+	return rep, nil
+}
+
+// SAN without check marks and annotations.
+func plainSAN(move string) string {
+	return strings.TrimRight(move, "+#!?")
+}
+
+// The game's moves in plain SAN.
+func gameSAN(game *chess.Game) []string {
+	positions := game.Positions()
+	moves := make([]string, 0, len(game.Moves()))
+	for i, move := range game.Moves() {
+		moves = append(moves, plainSAN(chess.Encoder.Encode(chess.AlgebraicNotation{}, positions[i], move)))
+	}
+	return moves
+}
+
+// Lines continuing the game's moves.
+func (r *repertoire) continuations(history []string) [][]string {
+	var lines [][]string
+	for _, line := range r.lines {
+		if len(line) <= len(history) {
+			continue
+		}
+		match := true
+		for i, move := range history {
+			if line[i] != move {
+				match = false
+				break
+			}
+		}
+		if match {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Repertoire moves in the game's position, none once out of the repertoire.
+func (r *repertoire) next(game *chess.Game) []string {
+	history := gameSAN(game)
+	var moves []string
+	seen := map[string]bool{}
+	for _, line := range r.continuations(history) {
+		if move := line[len(history)]; !seen[move] {
+			seen[move] = true
+			moves = append(moves, move)
+		}
+	}
+	return moves
+}
+
+// Check the human's move against the repertoire. A deviation is refused once
+// with the expected moves, playing it again leaves the repertoire.
+func (r *repertoire) allow(game *chess.Game, moveStr string) bool {
+	expected := r.next(game)
+	if len(expected) == 0 {
+		return true
+	}
+	move, err := decodeMove(game, moveStr)
+	if err != nil { // Let the move path explain.
+		return true
+	}
+	san := plainSAN(chess.Encoder.Encode(chess.AlgebraicNotation{}, game.Position(), move))
+	for _, m := range expected {
+		if m == san {
+			return true
+		}
+	}
+
+	if r.warned == san {
+		fmt.Println("Leaving the repertoire.")
+		return true
+	}
+	r.warned = san
+	fmt.Println(gConsole.Bold(gConsole.Red(san)).String()+" is not in your repertoire, expected",
+		gConsole.Bold(gConsole.Yellow(strings.Join(expected, " or "))).String()+". Play it again to leave the repertoire.")
+	return false
+}
+
+// Engine's reply from the repertoire, steering to the least drilled line.
+func (r *repertoire) reply(game *chess.Game) *chess.Move {
+	history := gameSAN(game)
+	var best []string
+	for _, line := range r.continuations(history) {
+		if best == nil || r.drilled[strings.Join(line, " ")] < r.drilled[strings.Join(best, " ")] {
+			best = line
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	move, err := chess.AlgebraicNotation{}.Decode(game.Position(), best[len(history)])
+	if err != nil { // A typo in the repertoire.
+		return nil
+	}
+	return move
+}
+
+// Count the lines the game has played to the end, once per game.
+func (r *repertoire) drill(game *chess.Game) {
+	history := gameSAN(game)
+	for _, line := range r.lines {
+		key := strings.Join(line, " ")
+		if len(line) > len(history) || key != strings.Join(history[:len(line)], " ") || r.played[key] {
+			continue
+		}
+		r.played[key] = true
+		r.drilled[key]++
+		fmt.Println("Line drilled:", key, gConsole.Bold(gConsole.Yellow(fmt.Sprintf("(%dx)", r.drilled[key]))))
+		r.save()
+	}
+}
+
+// Plies of the game played by the book, up to the last position in the repertoire.
+func (r *repertoire) bookPly(game *chess.Game) int {
+	history := gameSAN(game)
+	ply := 0
+	for _, line := range r.lines {
+		n := 0
+		for n < len(line) && n < len(history) && line[n] == history[n] {
+			n++
+		}
+		if n > ply {
+			ply = n
+		}
+	}
+	return ply
+}
+
+// Forget the lines completed past the ply, they count again when replayed.
+func (r *repertoire) rewind(ply int) {
+	for key := range r.played {
+		if len(strings.Fields(key)) > ply {
+			delete(r.played, key)
+		}
+	}
+	r.warned = ""
+}
+
+// Save the drill counts.
+func (r *repertoire) save() {
+	var dat strings.Builder
+	for key, n := range r.drilled {
+		fmt.Fprintf(&dat, "%d\t%s\n", n, key)
+	}
+	if err := ioutil.WriteFile(r.path+".drilled", []byte(dat.String()), 0644); err != nil {
+		fmt.Println("Unable to save the drill counts,", err)
+	}
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/chzyer/readline"
+)
+
+// Redraw the board and the prompt when the terminal is resized, a resize leaves
+// stale pieces of the old ones behind.
+func watchResize(l *readline.Instance) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+	go func() {
+		for range sig {
+			time.Sleep(100 * time.Millisecond) // Dragging the window sends a burst, draw once it settles.
+			for len(sig) > 0 {
+				<-sig
+			}
+			gResize.redraw(l)
+		}
+	}()
+}
@@ -0,0 +1,173 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+)
+
+// reviewCmd steps through a saved game.
+var reviewCmd = &cobra.Command{
+	Use:   "review FILE",
+	Short: "Step through a saved game, move by move",
+	Long: `Step through a saved game. next (or an empty line) and prev step a move,
+next-capture and next-check fast-forward to the next capture or check, first
+and last jump to either end. bookmark <name> names the position to come back
+to with goto-bookmark <name>, bookmarks lists them. /quit ends the review.`,
+	Example: `  pinata review pinata.pgn -v`,
+	Args:    cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		game := readPGN(nil, args[0])
+		if game == nil {
+			os.Exit(1)
+		}
+
+		l, err := readline.NewEx(&readline.Config{
+			AutoComplete: readline.NewPrefixCompleter(
+				readline.PcItem("next"), readline.PcItem("prev"),
+				readline.PcItem("next-capture"), readline.PcItem("next-check"),
+				readline.PcItem("first"), readline.PcItem("last"), readline.PcItem("/quit"),
+				readline.PcItem("bookmark"), readline.PcItem("goto-bookmark"), readline.PcItem("bookmarks"),
+			),
+		})
+		if err != nil {
+			panic(err)
+		}
+		defer l.Close()
+
+		evals := loadEvals(game, args[0]) // Analysis saved with the game, if any.
+		printEvalGraph(evals)
+		printAccuracy(game, evals)
+		review(l, game, args[0])
+	},
+}
+
+// Step through the game's moves as asked. Bookmarks are saved next to the file.
+func review(l *readline.Instance, game *chess.Game, filename string) {
+	moves := game.Moves()
+	ply := 0
+	bookmarks := loadBookmarks(game, filename)
+	if bookmarks == nil {
+		bookmarks = map[string]int{}
+	}
+	drawBoard(rewindGame(game, ply))
+
+	for {
+		l.SetPrompt(gConsole.Faint(fmt.Sprintf("review %d/%d", ply, len(moves))).String() + " ")
+		cmd, err := l.Readline()
+		if err == readline.ErrInterrupt || err == io.EOF {
+			return
+		}
+
+		to := ply
+		switch strings.TrimSpace(cmd) {
+		case "", "next":
+			to = ply + 1
+		case "prev":
+			to = ply - 1
+		case "first":
+			to = 0
+		case "last":
+			to = len(moves)
+		case "next-capture":
+			to = nextMoment(moves, ply, func(move *chess.Move) bool {
+				return move.HasTag(chess.Capture) || move.HasTag(chess.EnPassant)
+			})
+			if to < 0 {
+				fmt.Println("No more captures till the end of the game.")
+				continue
+			}
+		case "next-check":
+			to = nextMoment(moves, ply, func(move *chess.Move) bool { return move.HasTag(chess.Check) })
+			if to < 0 {
+				fmt.Println("No more checks till the end of the game.")
+				continue
+			}
+		case "/quit":
+			return
+		case "bookmarks":
+			printBookmarks(game, bookmarks)
+			continue
+		default:
+			args := strings.Fields(cmd)
+			switch {
+			case len(args) == 2 && args[0] == "bookmark":
+				bookmarks[args[1]] = ply
+				if !dryRun(bookmarkPath(filename)) {
+					saveBookmarks(game, filename, bookmarks)
+				}
+				fmt.Println("Bookmarked as", gConsole.Bold(gConsole.Yellow(args[1])).String()+".")
+				continue
+			case len(args) == 2 && args[0] == "goto-bookmark":
+				bookmarked, ok := bookmarks[args[1]]
+				if !ok {
+					fmt.Println("No bookmark", gConsole.Bold(gConsole.Yellow(args[1])).String()+".")
+					continue
+				}
+				to = bookmarked
+			default:
+				fmt.Println("Review with", gConsole.Bold(gConsole.Yellow("next prev next-capture next-check first last bookmark goto-bookmark")).String(),
+					"or", gConsole.Bold(gConsole.Yellow("/quit")).String()+".")
+				continue
+			}
+		}
+
+		switch {
+		case to < 0:
+			fmt.Println("This is the start of the game.")
+			continue
+		case to > len(moves):
+			if game.Outcome() == chess.NoOutcome {
+				fmt.Println("This is the end of the game.")
+			} else {
+				fmt.Println("This is the end of the game,", game.Outcome().String()+".")
+			}
+			continue
+		}
+		ply = to
+		if ply > 0 { // The move that led here.
+			before := rewindGame(game, ply-1)
+			fmt.Println(moveNumber(before.Position()) + showMove(before, moves[ply-1]))
+		} else {
+			fmt.Println("Back at the start of the game.")
+		}
+		drawBoard(rewindGame(game, ply))
+	}
+}
+
+// Ply after the next move from the ply on that matches, -1 if none.
+func nextMoment(moves []*chess.Move, ply int, match func(*chess.Move) bool) int {
+	for i := ply; i < len(moves); i++ {
+		if match(moves[i]) {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/abperiasamy/chess"
 	"github.com/spf13/cobra"
@@ -34,14 +35,23 @@ var rootCmd = &cobra.Command{
 
 	// Transfer control to readline shell.
 	Run: func(cmd *cobra.Command, args []string) {
-		onStart() // Perform post initialization
-		shell()   // Shell controls the game interaction from start to finish.
-		onStop()  // Perform cleanup
+		onStart(cmd) // Perform post initialization
+		if gWatch {
+			watch() // The engine plays itself.
+		} else {
+			shell() // Shell controls the game interaction from start to finish.
+		}
+		onStop() // Perform cleanup
 	},
 }
 
 // Perform post initialization routines right before starting the game.
-func onStart() {
+func onStart(cmd *cobra.Command) {
+	loadConfig(cmd)
+	useProfile(cmd)
+	useStartPosition(cmd)
+	gEngineConfigured = cmd.Flags().Changed("engine")
+	gThreadsConfigured = cmd.Flags().Changed("threads")
 	initGlobals()
 
 	// Invert colors on a brighter background
@@ -54,6 +64,14 @@ func onStart() {
 	chess.ConsoleColor = !gNoColor
 	chess.ConsoleUnicode = !gNoColor // also disable unicode printing
 
+	// Let the clock or the nodes limit drive the engine's search unless a depth is asked for.
+	if (gClock != nil || gEngineNodes > 0) && !cmd.Flags().Changed("depth") {
+		gEngineDepth = 0
+	}
+
+	if gDryRun {
+		fmt.Println(gConsole.Bold(gConsole.Yellow("Dry run")).String() + ", nothing is written.")
+	}
 }
 
 // Perform post initialization routines right after the game ends.
@@ -71,53 +89,89 @@ func Execute() {
 // Load config file and register flags.
 func init() {
 	// fmt.Print("\033[?25l") // Hide cursor
-	// Initialize config and ENV first. Command-line flags may override these settings.
-	// cobra.OnInitialize(initConfig)
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVarP(&gCfgFile, "config", "c", "pinata.toml", "config file")
-	rootCmd.PersistentFlags().StringVarP(&gEngineBinary, "engine", "e", "stockfish", "path to UCI compatible chess engine executable")
-	rootCmd.PersistentFlags().StringVarP(&gGamePath, "file", "f", "", "load game from a PGN file")
+	rootCmd.PersistentFlags().StringVarP(&gCfgFile, "config", "c", "pinata.toml", "config file, command-line flags override its settings")
+	rootCmd.PersistentFlags().StringVarP(&gEngineBinary, "engine", "e", "stockfish", "path to UCI compatible chess engine executable, \"builtin\" or \"random\"")
+	rootCmd.PersistentFlags().StringVar(&gEngineLogFile, "log-engine", "", "log the conversation with the engine to this file")
+	rootCmd.PersistentFlags().StringVar(&gSessionLogFile, "session-log", "", "keep a diary of every move of the session with the time in this file")
+	rootCmd.PersistentFlags().StringVarP(&gGamePath, "file", "f", "", "load game from a PGN file (\"-\" reads the standard input)")
+	rootCmd.PersistentFlags().StringVar(&gStartFEN, "fen", "", "start the game from a FEN position")
+	rootCmd.PersistentFlags().StringVar(&gStartName, "start", "", "start the game from a named position like italian-game, see the positions command")
+	rootCmd.PersistentFlags().IntVar(&gAutosaveEvery, "autosave-every", 0, "save the game in progress every this many moves (0 only when it ends or you quit)")
+	rootCmd.PersistentFlags().BoolVar(&gConfirmSave, "confirm-overwrite", true, "ask before a save replaces a different game")
 	rootCmd.PersistentFlags().StringVarP(&gLichessAuthTok, "analyze", "a", "", "lichess.org API access-token to analyze the game")
 	rootCmd.PersistentFlags().BoolVarP(&gHumanIsBlack, "black", "b", false, "choose the black side")
 	rootCmd.PersistentFlags().BoolVarP(&gVisual, "visual", "v", false, "cheat blindfold")
+	rootCmd.PersistentFlags().BoolVar(&gWatch, "watch", false, "watch the engine play against itself")
+	rootCmd.PersistentFlags().DurationVar(&gMinReplyTime, "min-reply-time", 0, "show the engine's move no sooner than this after yours (e.g. 2s), its search is not affected")
+	rootCmd.PersistentFlags().DurationVar(&gWatchDelay, "delay", time.Second, "pause between the moves in watch mode")
+	rootCmd.PersistentFlags().BoolVar(&gTwoPlayer, "two-player", false, "two humans play each other, no engine")
+	rootCmd.PersistentFlags().BoolVar(&gAutoFlip, "auto-flip", false, "turn the board to the player to move in two-player mode")
+	rootCmd.PersistentFlags().StringVar(&gWhiteName, "white-name", "", "white player's name in the saved game (default White in two-player mode, else Human or the engine)")
+	rootCmd.PersistentFlags().StringVar(&gBlackName, "black-name", "", "black player's name in the saved game (default Black in two-player mode, else Human or the engine)")
+	rootCmd.PersistentFlags().BoolVar(&gHighlight, "highlight", false, "highlight the last move on the visual board")
+	rootCmd.PersistentFlags().BoolVar(&gCheckHighlight, "check-highlight", false, "highlight the king in check on the visual board, red, magenta when mated")
+	rootCmd.PersistentFlags().StringVar(&gCheckMarks, "check-marks", "+,#", "check and checkmate marks of the moves shown, the saved game keeps + and #")
+	rootCmd.PersistentFlags().BoolVar(&gDim, "dim", false, "muted colors to rest the eyes in long sessions, with any --palette")
+	rootCmd.PersistentFlags().StringVar(&gPalette, "palette", "default", "board colors [default|cb], cb is color-blind friendly")
+	rootCmd.PersistentFlags().StringVar(&gHistoryFile, "history", "", "keep the moves and commands entered for <UP> across sessions in this file")
+	rootCmd.PersistentFlags().BoolVar(&gNoCompletion, "no-completion", false, "do not complete moves with <TAB>, only commands")
+	rootCmd.PersistentFlags().StringVar(&gBoardLabels, "board-labels", "top-left", "where the board's coordinates go [top-left|top-right|bottom-left|bottom-right|both|none]")
+	rootCmd.PersistentFlags().StringVar(&gBoardBorder, "board-border", "grid", "lines of the board, around and between all squares, around the board only, or none [grid|outline|none]")
+	rootCmd.PersistentFlags().BoolVar(&gDualNotation, "dual-notation", false, "show the moves in SAN and coordinates, Nf3 (g1f3)")
+	rootCmd.PersistentFlags().StringVar(&gCastling, "castling", "O-O", "write castling in the moves shown and saved as [O-O|0-0]")
+	rootCmd.PersistentFlags().StringVar(&gProfile, "profile", "", "use the engine settings of a profile in the config file, see `pinata profiles`")
+	rootCmd.PersistentFlags().BoolVar(&gNoSaveConfig, "no-save-config", false, "do not offer to save the engine found on the first run to the config file")
+	rootCmd.PersistentFlags().BoolVar(&gDryRun, "dry-run", false, "print the files match, review, tag, export and move would write and the games match would play, without doing it")
+	rootCmd.PersistentFlags().StringVar(&gEnPassant, "en-passant", "", "mark en passant captures in the moves shown and saved, exd6 e.p. [e.p.]")
+	rootCmd.PersistentFlags().BoolVar(&gShowFEN, "show-fen", false, "print the FEN after every move")
+	rootCmd.PersistentFlags().StringVar(&gFENFile, "fen-file", "", "write the FEN to this file after every move, for external boards")
+	rootCmd.PersistentFlags().StringVar(&gEvalPerspective, "eval-perspective", "white", "show evaluations from White's side or yours, positive is good for you [white|human]")
+	rootCmd.PersistentFlags().BoolVar(&gThinking, "thinking", false, "show the engine's search depth, best move and eval while it thinks")
+	rootCmd.PersistentFlags().BoolVar(&gMoveDelta, "move-delta", false, "after the engine's reply, show how your move changed the eval and the material")
+	rootCmd.PersistentFlags().BoolVar(&gExplain, "explain", false, "explain the idea of every engine move in plain words, a rough guess for beginners (experimental)")
+	rootCmd.PersistentFlags().BoolVar(&gVerbose, "verbose", false, "explain every engine move, the depth, nodes, time and the line it chose")
+	rootCmd.PersistentFlags().BoolVar(&gPremove, "premove", false, "type your next move while the engine thinks, played if still legal")
 	if runtime.GOOS == "windows" { // disable color and unicode support on Windows by default
-		rootCmd.PersistentFlags().BoolVar(&gNoColor, "color", true, "disable colors")
+		rootCmd.PersistentFlags().StringVar(&gColorMode, "color", "never", "use colors [auto|always|never]")
 	} else {
-		rootCmd.PersistentFlags().BoolVar(&gNoColor, "no-color", false, "disable colors")
+		rootCmd.PersistentFlags().StringVar(&gColorMode, "color", "auto", "use colors [auto|always|never]")
 	}
+	rootCmd.PersistentFlags().BoolVar(&gNoColor, "no-color", false, "disable colors")
 	rootCmd.PersistentFlags().BoolVarP(&gLightBg, "light", "l", false, "invert the colors for lighter console background")
 	rootCmd.PersistentFlags().IntVarP(&gEngineDepth, "depth", "d", 10, "engine search depth")
+	rootCmd.PersistentFlags().IntVar(&gEngineNodes, "nodes", 0, "engine search nodes limit, the same strength on any hardware")
+	rootCmd.PersistentFlags().StringVar(&gTimeControl, "clock", "", "play with a chess clock, minutes+increment (e.g. 5+3)")
+	rootCmd.PersistentFlags().StringVar(&gTimeOdds, "time-odds", "", "give the sides different clocks, e.g. \"white=5+0 black=2+0\"")
+	rootCmd.PersistentFlags().StringVar(&gSyzygyPath, "syzygy", "", "path to Syzygy tablebases, draws the game in tablebase drawn endings")
+	rootCmd.PersistentFlags().BoolVar(&gDeadDraws, "dead-draws", false, "offer a draw when neither side can win with the material left")
+	rootCmd.PersistentFlags().StringVar(&gOnlyMoves, "only-moves", "", "point out positions with a single good move, before or after you move [before|after]")
+	rootCmd.PersistentFlags().IntVar(&gAutoResign, "auto-resign", 0, "offer to resign once the engine is this many centipawns ahead (0 never)")
+	rootCmd.PersistentFlags().IntVar(&gAutoResignMoves, "auto-resign-moves", 3, "engine moves the --auto-resign lead has to last")
+	rootCmd.PersistentFlags().IntVar(&gMateNotify, "mate-notify", 0, "tell when the engine has a forced mate against you within this many plies (0 never)")
+	rootCmd.PersistentFlags().IntVar(&gMateResign, "mate-resign", 0, "offer to resign when the engine has a forced mate within this many plies (0 never)")
+	rootCmd.PersistentFlags().IntVar(&gDrawOffers, "draw-offers", 0, "engine offers a draw after this many moves of level evaluation (0 never)")
+	rootCmd.PersistentFlags().IntVar(&gBlindfoldMoves, "blindfold-test", 0, "after this many moves played blind, set up the position from memory for a score")
+	rootCmd.PersistentFlags().StringVar(&gStatsFile, "stats", "pinata-stats.json", "keep the training scores in this file")
+	rootCmd.PersistentFlags().IntVar(&gHash, "hash", 0, "engine's hash table size in MB (0 keeps the engine's default)")
+	rootCmd.PersistentFlags().IntVar(&gThreads, "threads", gDefaultThreads, "engine's search threads")
+	rootCmd.PersistentFlags().StringVar(&gContempt, "contempt", "", "engine's contempt in centipawns, positive avoids draws, negative seeks them")
+	rootCmd.PersistentFlags().IntVar(&gMaxMoves, "max-moves", 0, "adjudicate the game after this many moves (0 plays to the end)")
+	rootCmd.PersistentFlags().StringVar(&gRepertoireFile, "repertoire", "", "drill the opening lines of this file, one line of SAN moves per line")
+	rootCmd.PersistentFlags().IntVar(&gVariety, "variety", 0, "how often the engine plays one of its other top moves for more varied games, 0 to 100, at the cost of strength")
+	rootCmd.PersistentFlags().Int64Var(&gSeed, "seed", 0, "seed of the random choices, the same seed repeats them (0 picks a new one)")
+	rootCmd.PersistentFlags().BoolVar(&gTeach, "teach", false, "print a teaching note when an instructive position comes up")
+	rootCmd.PersistentFlags().StringVar(&gNotesFile, "notes", "", "teaching notes file, a FEN and the note after a ';' per line (implies --teach)")
+	rootCmd.PersistentFlags().StringVar(&gLocaleNames, "san-locales", "de,nl", "also read the piece letters of these languages [de|nl|fr|es|it], fr, es and it write R for the king")
+	rootCmd.PersistentFlags().StringVar(&gMovesFile, "moves", "", "play the moves of this file for both sides before the game goes on")
+	rootCmd.PersistentFlags().StringVar(&gOpeningMoves, "opening-moves", "", "play these moves for both sides before the game goes on, e.g. \"1.e4 e5 2.Nf3\"")
+	rootCmd.PersistentFlags().StringVar(&gOnIllegal, "on-illegal", "abort", "on an illegal move of the --moves file [abort|skip|stop]")
+	rootCmd.PersistentFlags().StringVar(&gTCStyle, "tc-style", "normal", "engine time management [aggressive|normal|conservative]")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 }
-
-/*
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
-	if gCfgFile != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(gCfgFile)
-	} else {
-			// Find home directory.
-			home, err := homedir.Dir()
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-
-		// Search config in home directory with name ".pinata" (without extension).
-		// viper.AddConfigPath(home) // search first in the home dir.
-		viper.AddConfigPath(".") // search in the current dir.
-
-		// Default to TOML format, though viper supports a variety of standard formats.
-		viper.SetConfigName(gCfgFile)
-	}
-
-	viper.AutomaticEnv() // read in environment variables that match
-}
-*/
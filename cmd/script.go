@@ -0,0 +1,88 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Play the moves of the `--moves` file for both sides before the game goes on.
+// Moves are separated by spaces or lines, move numbers are optional and '#'
+// starts a comment. An illegal move is reported with its line and handled as
+// `--on-illegal` says: abort Piñata, skip the move or stop replaying. Returns
+// the number of moves played.
+func playMoves(game *chess.Game, filename string) int {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Println("Unable to read the moves,", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err = scanner.Err(); err != nil {
+		fmt.Println("Unable to read the moves,", err)
+		os.Exit(1)
+	}
+	return playLines(game, lines, func(n int) string { return filename + ":" + strconv.Itoa(n) + ":" })
+}
+
+// Play the `--opening-moves`, "1.e4 e5 2.Nf3", the way the `--moves` file is.
+func playOpeningMoves(game *chess.Game) int {
+	return playLines(game, []string{gOpeningMoves}, func(int) string { return "--opening-moves:" })
+}
+
+// Play the lines of moves, an illegal move is reported where the func says.
+func playLines(game *chess.Game, lines []string, where func(n int) string) int {
+	played := 0
+	for n, line := range lines {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		for _, field := range strings.Fields(line) {
+			moveStr := gMoveNumberRegex.ReplaceAllString(field, "")
+			if moveStr == "" {
+				continue
+			}
+			move, err := decodeMove(game, moveStr)
+			if err == nil && game.Outcome() == chess.NoOutcome {
+				game.Move(move)
+				played++
+				continue
+			}
+
+			fmt.Println(where(n+1), gConsole.Bold(gConsole.Red(moveStr)), "is not a legal move after", played, "moves.")
+			switch gOnIllegal {
+			case "abort":
+				os.Exit(1)
+			case "stop":
+				return played
+			}
+		}
+	}
+	return played
+}
@@ -0,0 +1,332 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/abperiasamy/chess"
+	"github.com/spf13/cobra"
+)
+
+var gServeAddr string
+
+// The game played from a browser with `pinata serve`. Requests take turns on
+// the game, the engine replies within the request of the human's move.
+type gameServer struct {
+	mu     sync.Mutex
+	game   *chess.Game
+	engine *uciEngine // nil when two humans play.
+}
+
+// State of the game as served at /game.
+type serverState struct {
+	FEN     string   `json:"fen"`
+	PGN     string   `json:"pgn"`
+	Turn    string   `json:"turn"`
+	Outcome string   `json:"outcome"`
+	Moves   []string `json:"moves"` // Legal moves in SAN.
+	Error   string   `json:"error,omitempty"`
+}
+
+// The page at /, a plain form to post moves without any script.
+const gServePage = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Piñata</title></head>
+<body style="font-family: sans-serif">
+%s
+<p><code>%s</code></p>
+<p>%s</p>
+<p style="color: #c00">%s</p>
+<form method="post" action="/move">
+<input type="hidden" name="page" value="1">
+<input name="move" autofocus placeholder="e4"> <button>Move</button>
+</form>
+<p>%s</p>
+</body>
+</html>
+`
+
+func (s *gameServer) state() serverState {
+	state := serverState{
+		FEN:     s.game.FEN(),
+		PGN:     pgnText(s.game),
+		Turn:    strings.ToLower(s.game.Position().Turn().Name()),
+		Outcome: s.game.Outcome().String(),
+		Moves:   []string{},
+	}
+	for _, move := range s.game.ValidMoves() {
+		state.Moves = append(state.Moves, encodeSAN(s.game.Position(), move))
+	}
+	return state
+}
+
+// Play the human's move and the engine's reply. The game is saved once over.
+func (s *gameServer) move(moveStr string) error {
+	if s.game.Outcome() != chess.NoOutcome {
+		return fmt.Errorf("the game is over, %s", s.game.Outcome())
+	}
+	if !gTwoPlayer && s.game.Position().Turn() != humanColor() {
+		return fmt.Errorf("it is the engine's turn")
+	}
+	fmt.Println(humanPrompt(s.game) + moveStr)
+	if err := playHumanMove(s.game, moveStr); err != nil {
+		return err
+	}
+	if gTwoPlayer {
+		drawBoard(s.game)
+	} else if s.game.Outcome() == chess.NoOutcome {
+		if err := engineMove(s.engine, s.game); err != nil {
+			return fmt.Errorf("engine failure, %v", err)
+		}
+	}
+	if isGameOver(s.game) {
+		saveGame(nil, s.game, gGameFilename)
+	}
+	return nil
+}
+
+func (s *gameServer) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.state()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, gServePage, boardSVG(s.game.Position().Board()), html.EscapeString(state.FEN),
+		html.EscapeString(strings.Join(gameSAN(s.game), " ")), html.EscapeString(r.URL.Query().Get("error")),
+		html.EscapeString(state.Outcome))
+}
+
+func (s *gameServer) handleGame(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeState(w, s.state(), http.StatusOK)
+}
+
+func (s *gameServer) handleFEN(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, s.game.FEN())
+}
+
+func (s *gameServer) handleBoard(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.Header().Set("Content-Type", "image/svg+xml")
+	fmt.Fprint(w, boardSVG(s.game.Position().Board()))
+}
+
+// POST /move with the move as a form value, "move=e4". The page's form is
+// sent back to the page, others get the game's state. Moves from other sites'
+// pages are refused, see ownRequest.
+func (s *gameServer) handleMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "POST a move, e.g. move=e4", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ownRequest(r) {
+		http.Error(w, "Moves are taken from this server's own page only", http.StatusForbidden)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.move(strings.TrimSpace(r.FormValue("move")))
+	if r.FormValue("page") != "" { // The page tells what went wrong.
+		page := "/"
+		if err != nil {
+			page += "?error=" + url.QueryEscape(err.Error())
+		}
+		http.Redirect(w, r, page, http.StatusSeeOther)
+		return
+	}
+	state, status := s.state(), http.StatusOK
+	if err != nil {
+		state.Error, status = err.Error(), http.StatusUnprocessableEntity
+	}
+	writeState(w, state, status)
+}
+
+func writeState(w http.ResponseWriter, state serverState, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(state)
+}
+
+// Is the address on this machine only?
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Is the request addressed to this server, and sent from its own page if it
+// comes from a browser? A page of another site could otherwise post moves, or
+// reach the server through a name of its own that resolves here (DNS rebinding).
+// Programs like curl send no Origin.
+func ownRequest(r *http.Request) bool {
+	if !ownHost(r.Host) {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Scheme == "http" && ownHost(u.Host)
+}
+
+// Is the host, "localhost:8080", the address served on? A server on this
+// machine only also answers to localhost and the loopback addresses, one on all
+// addresses to this machine's addresses and host name as well.
+func ownHost(hostport string) bool {
+	host, port := splitHostPort(hostport)
+	listenHost, listenPort := splitHostPort(gServeAddr)
+	if port != listenPort {
+		return false
+	}
+	host = strings.ToLower(host)
+	if host == strings.ToLower(listenHost) {
+		return true
+	}
+	ip := net.ParseIP(host)
+	loopback := host == "localhost" || ip != nil && ip.IsLoopback()
+	if loopbackAddr(gServeAddr) {
+		return loopback
+	}
+	if listenIP := net.ParseIP(listenHost); listenHost != "" && (listenIP == nil || !listenIP.IsUnspecified()) {
+		return false // Served on one address only.
+	}
+	if name, err := os.Hostname(); loopback || err == nil && host == strings.ToLower(name) {
+		return true
+	}
+	addrs, _ := net.InterfaceAddrs()
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ip != nil && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Host and port of an address, port 80 when there is none.
+func splitHostPort(hostport string) (host, port string) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return hostport, "80"
+	}
+	return host, port
+}
+
+// serveCmd plays the game from a browser.
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Play from a browser or another program over HTTP",
+	Long: `Serve the game over HTTP on this machine:
+
+  GET  /           a page with the board and a form to move
+  GET  /game       the game as JSON: FEN, PGN, turn, outcome and the legal moves
+  GET  /fen        the FEN
+  GET  /board.svg  the board as an SVG image
+  POST /move       play a move, "move=e4", the engine replies right away
+
+Moves posted from the pages of other sites are refused. The game is saved like
+in the shell once it is over, or when stopped with Ctrl-C.`,
+	Example: `  pinata serve
+  curl -d move=e4 localhost:8080/move`,
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		if !loopbackAddr(gServeAddr) {
+			fmt.Println(gConsole.Bold(gConsole.Red("Warning:")), "anyone who can reach", gServeAddr, "can play the game.")
+		}
+
+		s := &gameServer{game: newGame()}
+		if gGamePath != "" {
+			if s.game = loadPGN(nil, gGamePath); s.game == nil {
+				os.Exit(1)
+			}
+		}
+		gGame = s.game // The prompt and the board look at the game in play.
+		syncMoveCount(s.game)
+		if !gTwoPlayer {
+			eng, err := newEngine(gEngineBinary)
+			if err != nil {
+				os.Exit(1)
+			}
+			defer eng.Close()
+			setContempt(eng)
+			eng.IsReady()
+			s.engine = eng
+			if s.game.Outcome() == chess.NoOutcome && s.game.Position().Turn() != humanColor() {
+				engineMove(eng, s.game)
+			}
+		}
+
+		// Save the game on the way out.
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		go func() {
+			<-interrupt
+			s.mu.Lock()
+			if len(s.game.Moves()) > 0 && s.game.Outcome() == chess.NoOutcome {
+				saveGame(nil, s.game, gGameFilename)
+			}
+			if s.engine != nil {
+				s.engine.Close()
+			}
+			os.Exit(0)
+		}()
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", s.handlePage)
+		mux.HandleFunc("/game", s.handleGame)
+		mux.HandleFunc("/fen", s.handleFEN)
+		mux.HandleFunc("/board.svg", s.handleBoard)
+		mux.HandleFunc("/move", s.handleMove)
+		fmt.Println("Serving the game on", gConsole.Bold(gConsole.Yellow("http://"+gServeAddr+"/")).String()+", Ctrl-C to stop.")
+		if err := http.ListenAndServe(gServeAddr, mux); err != nil {
+			fmt.Println("Unable to serve the game,", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&gServeAddr, "listen", "127.0.0.1:8080", "address to serve on, this machine only unless told otherwise")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/abperiasamy/chess"
+)
+
+func TestOwnRequest(t *testing.T) {
+	defer func(addr string) { gServeAddr = addr }(gServeAddr)
+	tests := []struct {
+		listen, host, origin string
+		want                 bool
+	}{
+		{"127.0.0.1:8080", "127.0.0.1:8080", "", true},
+		{"127.0.0.1:8080", "localhost:8080", "http://localhost:8080", true},
+		{"127.0.0.1:8080", "[::1]:8080", "", true},
+		{"127.0.0.1:8080", "localhost:8080", "http://evil.example", false},
+		{"127.0.0.1:8080", "localhost:8080", "null", false},
+		{"127.0.0.1:8080", "evil.example:8080", "", false}, // DNS rebinding.
+		{"127.0.0.1:8080", "localhost:9090", "", false},
+		{"localhost:80", "localhost", "http://localhost", true},
+		{"192.168.1.5:8080", "192.168.1.5:8080", "http://192.168.1.5:8080", true},
+		{"192.168.1.5:8080", "localhost:8080", "", false},
+		{":8080", "localhost:8080", "", true},
+		{":8080", "evil.example:8080", "", false},
+	}
+	for _, tt := range tests {
+		gServeAddr = tt.listen
+		r := httptest.NewRequest(http.MethodPost, "/move", nil)
+		r.Host = tt.host
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := ownRequest(r); got != tt.want {
+			t.Errorf("listening on %s, Host %s, Origin %q: ownRequest = %v, want %v", tt.listen, tt.host, tt.origin, got, tt.want)
+		}
+	}
+}
+
+// A move posted from another site is refused and not played.
+func TestHandleMoveOtherOrigin(t *testing.T) {
+	defer func(addr string, twoPlayer bool) { gServeAddr, gTwoPlayer = addr, twoPlayer }(gServeAddr, gTwoPlayer)
+	gServeAddr, gTwoPlayer = "127.0.0.1:8080", true
+	s := &gameServer{game: chess.NewGame(chess.UseNotation(chess.AlgebraicNotation{}))}
+
+	tests := []struct {
+		origin string
+		status int
+		moves  int
+	}{
+		{"http://evil.example", http.StatusForbidden, 0},
+		{"http://127.0.0.1:8080", http.StatusOK, 1},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "http://127.0.0.1:8080/move", strings.NewReader(url.Values{"move": {"e4"}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		s.handleMove(w, r)
+		if w.Code != tt.status {
+			t.Errorf("Origin %s: status %d, want %d", tt.origin, w.Code, tt.status)
+		}
+		if got := len(s.game.Moves()); got != tt.moves {
+			t.Errorf("Origin %s: %d moves played, want %d", tt.origin, got, tt.moves)
+		}
+	}
+}
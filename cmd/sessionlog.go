@@ -0,0 +1,123 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Diary of every move of the session with the time it was played, see
+// `--session-log`. It spans all the games of the run, lines tried in the
+// sandbox are left out.
+type sessionLog struct {
+	mu    sync.Mutex // The engine replies in the background with `--premove`.
+	file  *os.File
+	games int         // Games logged in this session.
+	game  *chess.Game // Game of the moves logged last.
+	ply   int         // Moves of it logged.
+	ended bool        // Its result is logged.
+}
+
+// Open the log for appending, sessions follow one another.
+func openSessionLog(path string) (*sessionLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	log := &sessionLog{file: file}
+	log.record("session started")
+	return log, nil
+}
+
+func (l *sessionLog) record(line string) {
+	fmt.Fprintf(l.file, "%s %s\n", time.Now().Format("2006-01-02 15:04:05"), line)
+}
+
+// Log the moves played since the last call. A game other than the last one
+// starts a new game in the log, a rewound one takes the moves back.
+func (l *sessionLog) moves(game *chess.Game) {
+	if l == nil || gSandbox != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if game != l.game && (l.game == nil || !sameGame(game, l.game)) {
+		l.endGame()
+		l.games++
+		l.game, l.ply, l.ended = game, len(game.Moves()), false
+		line := "game " + strconv.Itoa(l.games) + ": " + sessionPlayers()
+		if start := game.Positions()[0].String(); start != gStandardFEN {
+			line += ", from " + start
+		}
+		if l.ply > 0 {
+			line += ", resumed at " + strings.TrimSpace(moveNumber(game.Position()))
+		}
+		l.record(line)
+	}
+	l.game = game
+
+	moves, positions := game.Moves(), game.Positions()
+	if len(moves) < l.ply {
+		l.record("took back to " + strings.TrimSpace(moveNumber(positions[len(moves)])))
+		l.ply = len(moves)
+	}
+	for ; l.ply < len(moves); l.ply++ {
+		pos := positions[l.ply]
+		l.record(moveNumber(pos) + encodeSAN(pos, moves[l.ply]))
+	}
+	if game.Outcome() != chess.NoOutcome && !l.ended {
+		l.endGame()
+	}
+}
+
+// Log the result of the last game, once the session is over.
+func (l *sessionLog) end() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.endGame()
+}
+
+func (l *sessionLog) endGame() {
+	if l.game == nil || l.ended {
+		return
+	}
+	l.ended = true
+	if l.game.Outcome() == chess.NoOutcome {
+		l.record("game " + strconv.Itoa(l.games) + " left unfinished")
+		return
+	}
+	l.record("game " + strconv.Itoa(l.games) + " ended " + l.game.Outcome().String() + " (" + endMethod(l.game) + ")")
+}
+
+// Who plays whom, "Human vs stockfish".
+func sessionPlayers() string {
+	if gWatch {
+		return gEngineBinary + " vs " + gEngineBinary
+	}
+	return playerName(chess.White) + " vs " + playerName(chess.Black)
+}
@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -54,11 +55,87 @@ func completeLoad(path string) func(string) []string {
 	}
 }
 
+// Ask a yes or no question. Anything but yes is a no.
+func confirm(l *readline.Instance, question string) bool {
+	if l == nil { // No input left to answer.
+		return false
+	}
+
+	l.SetPrompt(question + " [y/N] ")
+	answer, err := l.Readline()
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// Ask for a line of text, "" if there is no answer.
+func ask(l *readline.Instance, question string) string {
+	if l == nil { // No input left to answer.
+		return ""
+	}
+
+	l.SetPrompt(question + " ")
+	answer, err := l.Readline()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(answer)
+}
+
+// Save the game, asking first if the file holds a different game. When the
+// file can not be written, say a read-only or full disk, another file is asked
+// for until the game is saved or the human gives up. Returns true if the game
+// was saved.
+func saveGame(l *readline.Instance, game *chess.Game, filename string) bool {
+	if dryRun(filename) {
+		return false
+	}
+	if gConfirmSave && overwritesOtherGame(game, filename) &&
+		!confirm(l, filename+" holds a different game. Overwrite it?") {
+		fmt.Println("Game not saved.")
+		return false
+	}
+
+	err := savePGN(game, filename)
+	for err != nil {
+		other := ask(l, "Save the game to another file instead? Its name, or enter to give up:")
+		if other == "" {
+			fmt.Println(gConsole.Bold(gConsole.Red("Game not saved.")))
+			return false
+		}
+		if gConfirmSave && overwritesOtherGame(game, other) &&
+			!confirm(l, other+" holds a different game. Overwrite it?") {
+			continue
+		}
+		if err = savePGN(game, other); err == nil {
+			filename, gGameFilename = other, other // Later saves and autosaves go there too.
+		}
+	}
+	fmt.Println("Game saved to", gConsole.Bold(gConsole.Red(filename)))
+	return true
+}
+
+// Resume a newly set up game. The engine moves right away if it is its turn.
+// Returns true if the game ended.
+func engineTurn(eng *uciEngine, game *chess.Game) bool {
+	syncMoveCount(game)
+	if gTwoPlayer || game.Position().Turn() == humanColor() {
+		return false
+	}
+	if err := engineMove(eng, game); err != nil {
+		return false
+	}
+	return isGameOver(game)
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func shell() {
 	// Initialize a new game and save it in global gGame.
-	gGame = chess.NewGame(chess.UseNotation(chess.AlgebraicNotation{}))
+	gGame = newGame()
 
 	// Load game from PGN.
 	if gGamePath != "" {
@@ -66,107 +143,423 @@ func shell() {
 		// Append default name to dir if empty.
 		fInfo, err := os.Stat(filename)
 		if err == nil && fInfo.IsDir() {
-			filename = filepath.Clean(filepath.Join(filename) + "/" + gGameFilename)
+			filename = filepath.Clean(filepath.Join(filename) + "/" + gDefaultGameFilename)
 		}
 
-		// Check if file exist.
-		if _, err := os.Stat(filename); os.IsNotExist(err) {
+		// Check if file exist. "-" is the standard input.
+		if _, err := os.Stat(filename); os.IsNotExist(err) && filename != "-" {
 			fmt.Println(gConsole.Bold(gConsole.Red(filename)), "does not exist.")
 			os.Exit(1)
 		}
 
-		if gGame = loadPGN(filename); gGame == nil { // Failed to load the PGN.
+		if gGame = loadPGN(nil, filename); gGame == nil { // Failed to load the PGN.
 			// fmt.Println("Unable to open " + gConsole.Bold(gConsole.Red(filename)).String() + ".")
 			os.Exit(1)
 		}
+	}
 
-		// Check to see if the game already ended.
-		if isGameOver(gGame) {
-			drawBoard(gGame)
-			os.Exit(0)
+	// Check to see if the game already ended, a loaded game or a set up position
+	// may have no moves left to play.
+	if isGameOver(gGame) {
+		drawBoard(gGame)
+		printEvalGraph(gEvals)
+		os.Exit(0)
+	}
+
+	if gRepertoireFile != "" {
+		rep, err := loadRepertoire(gRepertoireFile)
+		if err != nil {
+			fmt.Println("Unable to load the repertoire,", err)
+			os.Exit(1)
 		}
+		gRepertoire = rep
 	}
 
-	eng, err := newEngine(gEngineBinary)
-	if err != nil {
-		log.Fatal(err)
+	var eng *uciEngine // No engine when two humans play.
+	if !gTwoPlayer {
+		var err error
+		eng, err = newEngine(gEngineBinary)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer eng.Close()
+		if gSyzygyPath != "" {
+			if eng.HasOption("SyzygyPath") {
+				eng.SendOption("SyzygyPath", gSyzygyPath)
+			} else {
+				fmt.Println(gConsole.Bold(gConsole.Red(gEngineBinary)), "does not support Syzygy tablebases.")
+			}
+		}
+		setContempt(eng)
+		eng.IsReady()
 	}
-	defer eng.Close()
-	eng.SendOption("Threads", "8")
 
 	completer := readline.NewPrefixCompleter(
 		readline.PcItemDynamic(validMovesConstructor()),
 		readline.PcItem("resign"),
 		readline.PcItem("/fen"),
+		readline.PcItem("/fens"),
 		readline.PcItem("/save", readline.PcItem(gGameFilename)),
 		readline.PcItem("/load", readline.PcItemDynamic(completeLoad("."))),
+		readline.PcItem("/copy"),
+		readline.PcItem("/pgn"),
 		readline.PcItem("/visual"),
+		readline.PcItem("/arrow", readline.PcItem("clear")),
+		readline.PcItem("/swap"),
+		readline.PcItem("/coach"),
+		readline.PcItem("/describe"),
+		readline.PcItem("/attackers"),
+		readline.PcItem("/mobility"),
+		readline.PcItem("/sandbox"),
+		readline.PcItem("/analyze"),
+		readline.PcItem("/back"),
+		readline.PcItem("/forward"),
+		readline.PcItem("/lines"),
+		readline.PcItem("/play"),
+		readline.PcItem("/book"),
+		readline.PcItem("/bookmark"),
+		readline.PcItem("/goto-bookmark"),
+		readline.PcItem("/bookmarks"),
+		readline.PcItem("/return"),
+		readline.PcItem("/pause"),
+		readline.PcItem("/resume"),
 		readline.PcItem("/quit"),
+		readline.PcItem("/bindings"),
 		readline.PcItem("/keys",
 			readline.PcItem("vi"),
 			readline.PcItem("emacs"),
 		))
 
-	l, err := readline.NewEx(&readline.Config{
+	cfg := &readline.Config{
 		// Prompt: "\033[31m»\033[0m ",
-		// HistoryFile:     "/tmp/readline.tmp",
+		HistoryFile:         gHistoryFile,
 		AutoComplete:        completer,
 		InterruptPrompt:     "/quit",
 		EOFPrompt:           "\n",
 		HistorySearchFold:   true,
 		FuncFilterInputRune: filterInput,
-	})
+		// Only moves and commands, not the answers to questions.
+		DisableAutoSaveHistory: true,
+	}
+	if gGamePath == "-" { // The game came through the standard input, read the moves from the terminal.
+		if err := useTTY(cfg); err != nil {
+			fmt.Println("Unable to open the terminal,", err)
+			os.Exit(1)
+		}
+	}
+
+	l, err := readline.NewEx(cfg)
 	if err != nil {
 		panic(err)
 	}
 	defer l.Close()
+	watchResize(l)
 
 	gameStarted := false
+	drawDeclined := false   // Stop offering dead draws once declined.
+	drawOffered := 0        // Evaluations seen at the engine's last draw offer.
+	resignOffered := 0      // Evaluations seen when last offered to resign.
+	mateDeclined := false   // Stop offering to resign to a mate once declined.
+	exploring := false      // Exploring on in the sandbox after the game ended.
+	var reply *pendingReply // Engine thinking in the background with `--premove`.
+
+	// Wrap up after a move, true if the game is over.
+	gameOver := func() bool {
+		if reason := deadDrawReason(gGame); reason != "" && !drawDeclined {
+			if confirm(l, "The position is a dead draw ("+reason+"). Agree to a draw?") {
+				gGame.Draw(chess.DrawOffer)
+			} else {
+				drawDeclined = true
+			}
+		}
+		if drawOffered > len(gEvals) { // A new game was set up.
+			drawOffered = 0
+		}
+		if gGame.Outcome() == chess.NoOutcome && levelEvals(gEvals[drawOffered:]) {
+			drawOffered = len(gEvals) // Not again before another stretch of level moves.
+			if confirm(l, "The engine offers a draw. Accept it?") {
+				gGame.Draw(chess.DrawOffer)
+			}
+		}
+		if resignOffered > len(gEvals) {
+			resignOffered = 0
+		}
+		if gGame.Outcome() == chess.NoOutcome && hopelessEvals(gEvals[resignOffered:], humanColor()) {
+			resignOffered = len(gEvals) // Not again before another stretch of lost moves.
+			if confirm(l, "The engine is well ahead and staying there. Resign?") {
+				gGame.Resign(humanColor())
+			}
+		}
+		if mate := mateAgainst(gLastInfo, humanColor()); mate > 0 && gGame.Outcome() == chess.NoOutcome && !gTwoPlayer {
+			if gMateNotify > 0 && 2*mate <= gMateNotify {
+				fmt.Println(gConsole.Bold(gConsole.Red(fmt.Sprintf("Mate in %d", mate))).String() + " against you.")
+			}
+			if gMateResign > 0 && 2*mate <= gMateResign && !mateDeclined {
+				if confirm(l, "The engine has a forced mate. Resign?") {
+					gGame.Resign(humanColor())
+				} else {
+					mateDeclined = true
+				}
+			}
+		}
+		if reason := adjudicate(gGame, gLastInfo); reason != "" {
+			fmt.Println("Game adjudicated:", reason+".")
+		}
+		if !isGameOver(gGame) {
+			return false
+		}
+		recordAccuracy(gGame)
+
+		// Save the game.
+		if saveGame(l, gGame, gGameFilename) {
+			// If analysis is request, upload the game to lichess.org and open it in a browser.
+			if gLichessAuthTok != "" {
+				lic := NewLichessClient(gLichessAuthTok, "Piñata "+gVersion)
+				_, url, err := lic.Import(gGameFilename)
+				if err != nil {
+					fmt.Println("Unable to export the game to https://lichess.org,", err)
+					return true
+				}
+				openbrowser(url)
+			}
+		}
+		return true
+	}
 
-	if humanColor() == chess.Black {
-		err = engineMoveFirst(eng, gGame)
+	syncMoveCount(gGame)
+	if isGameOver(gGame) { // No more moves to play.
+		return
+	}
+	gSessionLog.moves(gGame)
+	defer gSessionLog.end()
+	played := 0
+	if gMovesFile != "" {
+		played = playMoves(gGame, gMovesFile)
+	}
+	if gOpeningMoves != "" {
+		played = playOpeningMoves(gGame)
+	}
+	if played > 0 {
+		gameStarted = true
+		syncMoveCount(gGame)
+		gSessionLog.moves(gGame)
+		drawBoard(gGame)
+		if isGameOver(gGame) {
+			saveGame(l, gGame, gGameFilename)
+			return
+		}
+	}
+	if gBlindfoldMoves > 0 {
+		gBlindfold = &blindfoldTest{moves: gBlindfoldMoves, game: gGame, start: len(gGame.Moves())}
+	}
+	if !gTwoPlayer && gGame.Position().Turn() != humanColor() { // Engine's turn, also when resuming a game.
+		err = engineMove(eng, gGame)
 		if err != nil {
-			fmt.Errorf("Engine Failure: ", err)
+			fmt.Println("Engine failure:", err)
 			os.Exit(1)
 		}
 		gameStarted = true
+		if isGameOver(gGame) {
+			saveGame(l, gGame, gGameFilename)
+			return
+		}
 	}
 
+play:
 	for {
-		l.SetPrompt(humanPrompt())
+		if gTwoPlayer { // The player to move is at the keyboard.
+			gHumanIsBlack = gGame.Position().Turn() == chess.Black
+		}
+		if reply != nil { // The engine is still thinking.
+			reply.setPrompt(l)
+		} else {
+			mirrorFEN(gGame) // Whatever the last command did to the game.
+			gSessionLog.moves(gGame)
+			if gSandbox == nil {
+				autosave(gGame, gGameFilename)
+			}
+			if gBlindfold.due(gGame) {
+				gBlindfold.run(l, gGame)
+			}
+			if !gTwoPlayer && gSandbox == nil && gGame.Position().Turn() == humanColor() {
+				checkOnlyMove(eng, gGame) // Off the human's clock.
+			}
+			if gClock != nil { // Human's clock is ticking.
+				gClock.Start(humanColor())
+			}
+			l.SetPrompt(humanPrompt(gGame))
+			gResize.idle(gGame)
+		}
 		cmd, err := l.Readline()
-		if err == readline.ErrInterrupt {
+		gResize.idle(nil)
+		if err == readline.ErrInterrupt || err == io.EOF {
 			cmd = "/quit"
+		} else if strings.TrimSpace(cmd) != "" {
+			l.SaveHistory(cmd)
+		}
+		cmd = expandBinding(strings.TrimSpace(cmd))
+
+		if reply != nil { // Whatever the input, the engine's reply comes first.
+			premoved := !reply.replied()
+			replyErr := reply.wait(gGame)
+			reply = nil
+			if replyErr != nil {
+				fmt.Println("Engine failure:", replyErr)
+			}
+			if gRepertoire != nil {
+				gRepertoire.drill(gGame)
+			}
+			if gameOver() {
+				goto end
+			}
+			if premoved && cmd != "" && cmd != "resign" && !strings.HasPrefix(cmd, "/") {
+				cmd = checkPremove(gGame, cmd)
+			}
 		}
-		cmd = strings.TrimSpace(cmd)
 		switch {
 		case cmd == "": // no input, do nothing.
 
+		case gSandbox != nil && (cmd == "resign" || cmd == "/swap" || cmd == "/book" || strings.HasPrefix(cmd, "/fen ") ||
+			strings.HasPrefix(cmd, "/load") || strings.HasPrefix(cmd, "/save")):
+			fmt.Println("Leave the sandbox with", gConsole.Bold(gConsole.Yellow("/return")), "first.")
+
+		case gTwoPlayer && (cmd == "/swap" || cmd == "/coach" || strings.HasPrefix(cmd, "/lines")):
+			fmt.Println("There is no engine in two-player mode.")
+
+		case cmd == "/coach":
+			if isGameOver(gGame) {
+				continue
+			}
+			coach(eng, gGame)
+
+		case (cmd == "/pause" || cmd == "/resume") && gClock == nil:
+			fmt.Println("There is no clock to pause in this game.")
+
+		case cmd == "/pause" || cmd == "/resume":
+			if cmd == "/pause" {
+				gClock.Pause()
+				fmt.Println("Clock paused,", gConsole.Bold(gConsole.Yellow("/resume")), "to go on.")
+			} else {
+				gClock.Resume()
+			}
+
+		case cmd == "/mobility":
+			printMobility(gGame)
+
+		case cmd == "/describe":
+			describePosition(gGame.Position().Board())
+
+		case strings.HasPrefix(cmd, "/attackers"):
+			args := strings.Fields(cmd)[1:]
+			sq := chess.NoSquare
+			if len(args) == 1 {
+				sq, _ = parseSquare(args[0])
+			}
+			if sq == chess.NoSquare {
+				fmt.Println("Name the square, e.g.", gConsole.Bold(gConsole.Yellow("/attackers e5")))
+				continue
+			}
+			printAttackers(gGame.Position().Board(), sq)
+
+		case cmd == "/analyze":
+			if gSandbox != nil {
+				fmt.Println("Leave the sandbox with", gConsole.Bold(gConsole.Yellow("/return")), "first.")
+				continue
+			}
+			startAnalysis()
+
+		case (cmd == "/back" || cmd == "/forward" || strings.HasPrefix(cmd, "/lines")) && gAnalysis == nil:
+			fmt.Println("Only while analyzing,", gConsole.Bold(gConsole.Yellow("/analyze")), "first.")
+
+		case cmd == "/back":
+			gAnalysis.back()
+
+		case cmd == "/forward":
+			gAnalysis.forward()
+
+		case strings.HasPrefix(cmd, "/lines"):
+			printLines(eng, gGame, strings.Fields(cmd)[1:])
+
+		case cmd == "/play" && gAnalysis == nil:
+			fmt.Println("Not analyzing.")
+
+		case cmd == "/sandbox":
+			if gSandbox != nil {
+				fmt.Println("Already in the sandbox.")
+				continue
+			}
+			// Explore on a copy, the real game stays untouched.
+			gSandbox, gGame = gGame, gGame.Clone()
+			fmt.Println("Play moves for both sides,", gConsole.Bold(gConsole.Yellow("/return")), "to get back to the game.")
+
+		case cmd == "/return" || cmd == "/play":
+			if gSandbox == nil {
+				fmt.Println("Not in the sandbox.")
+				continue
+			}
+			gAnalysis = nil
+			if exploring { // The game is over, nothing to return to.
+				gGame, gSandbox = gSandbox, nil
+				goto end
+			}
+			gGame, gSandbox = gSandbox, nil
+			syncMoveCount(gGame)
+			fmt.Println("Back to the game.")
+			drawBoard(gGame)
+
+		case cmd == "/book":
+			if gRepertoire == nil {
+				fmt.Println("Drill a repertoire with", gConsole.Bold(gConsole.Yellow("--repertoire")), "to get back to the book.")
+				continue
+			}
+			ply := gRepertoire.bookPly(gGame)
+			if ply == len(gGame.Moves()) {
+				fmt.Println("Still in the book.")
+				continue
+			}
+
+			// Practice the move out of the book again.
+			gGame = rewindGame(gGame, ply)
+			gRepertoire.rewind(ply)
+			syncMoveCount(gGame)
+			gEvals = evalsBefore(gEvals, gMoveCount)
+			fmt.Println("Back to the book:", strings.Join(gameSAN(gGame), " "))
+			drawBoard(gGame)
+			if engineTurn(eng, gGame) {
+				goto end
+			}
+
 		case cmd == "resign":
 			gGame.Resign(humanColor())
 			isGameOver(gGame) // Game is over, but print the status.
 
 			// Save the game.
-			if savePGN(gGame, gGameFilename) == nil { // Success
-				fmt.Println("Game saved to", gConsole.Bold(gConsole.Red(gGameFilename)))
-			}
+			saveGame(l, gGame, gGameFilename)
 
 			goto end
 
+		case cmd == "/fens": // Every position of the game, for batch analysis
+			for _, pos := range gGame.Positions() {
+				fmt.Println(pos.String())
+			}
+
 		case strings.HasPrefix(cmd, "/fen"):
 			cmd := strings.SplitN(cmd, " ", 2)
 			if len(cmd) > 1 {
 				fenStr := cmd[1]
-				eng.SetFEN(fenStr)
-				fen, err := chess.FEN(fenStr)
+				fen, err := chess.FEN(sanitizeFEN(fenStr))
 				if err != nil {
 					fmt.Println("Not a valid FEN.")
 					continue
 				}
 				gGame = chess.NewGame(fen)
+				gEvals, gBookmarks = nil, nil
 				if isGameOver(gGame) { // No more moves to play.
 					goto end
 				}
+				if engineTurn(eng, gGame) {
+					goto end
+				}
 			} else { // Just display the current FEN
 				fmt.Println(gGame.FEN())
 			}
@@ -181,7 +574,7 @@ func shell() {
 			// Append default name to dir if empty.
 			fInfo, err := os.Stat(filename)
 			if err == nil && fInfo.IsDir() {
-				filename = filepath.Clean(filepath.Join(filename) + "/" + gGameFilename)
+				filename = filepath.Clean(filepath.Join(filename) + "/" + gDefaultGameFilename)
 			}
 
 			// Check if file exist.
@@ -192,12 +585,16 @@ func shell() {
 				filename += ".pgn"
 			}
 
-			g := loadPGN(filename)
+			g := loadPGN(l, filename)
 			if g != nil { // Success
-				gGame = g              // Overwrite the current game.
+				gGame = g // Overwrite the current game, loadPGN brought its evaluations.
+				gSessionLog.moves(gGame)
 				if isGameOver(gGame) { // No more moves to play.
 					goto end
 				}
+				if engineTurn(eng, gGame) {
+					goto end
+				}
 			}
 
 		case strings.HasPrefix(cmd, "/save"):
@@ -210,7 +607,7 @@ func shell() {
 			// Append default name to dir if empty.
 			fInfo, err := os.Stat(filename)
 			if err == nil && fInfo.IsDir() {
-				filename = filepath.Clean(filepath.Join(filename) + "/" + gGameFilename)
+				filename = filepath.Clean(filepath.Join(filename) + "/" + gDefaultGameFilename)
 			} else if !strings.HasSuffix(filename, ".pgn") {
 				if strings.HasSuffix(filename, ".") { // avoid generating "..pgn"
 					filename = strings.TrimSuffix(filename, ".")
@@ -218,9 +615,18 @@ func shell() {
 				filename += ".pgn"
 			}
 
-			if savePGN(gGame, filename) == nil { // Success
-				fmt.Println("Game saved to", gConsole.Bold(gConsole.Red(filename)))
+			saveGame(l, gGame, filename)
+
+		case cmd == "/pgn": // Plain text to copy from the terminal.
+			fmt.Println(savedPGNText(gGame))
+
+		case cmd == "/copy":
+			pgn := savedPGNText(gGame)
+			if err := copyToClipboard(pgn + "\n"); err != nil {
+				fmt.Println(pgn)
+				continue
 			}
+			fmt.Println("Game copied to the clipboard.")
 
 		case strings.HasPrefix(cmd, "/visual"):
 			if gVisual {
@@ -228,11 +634,104 @@ func shell() {
 				fmt.Println("You are playing", gConsole.Bold(gConsole.Yellow("blind")), "now.")
 			} else {
 				gVisual = true
+				gBlindfold.peek()
 				fmt.Println("You are playing", gConsole.Bold(gConsole.Yellow("visual")), "now.")
 				drawBoard(gGame)
 			}
 			continue
 
+		case strings.HasPrefix(cmd, "/arrow"):
+			args := strings.Fields(cmd)[1:]
+			if len(args) == 1 && args[0] == "clear" {
+				gArrows = nil
+				fmt.Println("No arrows on the board.")
+				drawBoard(gGame)
+				continue
+			}
+
+			arrows := []arrow{}
+			for _, arg := range args {
+				a, err := parseArrow(arg)
+				if err != nil {
+					fmt.Println("Arrows are written as squares, e.g.", gConsole.Bold(gConsole.Yellow("/arrow e2e4")))
+					break
+				}
+				arrows = append(arrows, a)
+			}
+			if len(arrows) < len(args) {
+				continue
+			}
+			if len(arrows) == 0 { // List the arrows.
+				for _, a := range gArrows {
+					fmt.Println(gConsole.Bold(gConsole.Yellow(a)))
+				}
+				continue
+			}
+
+			gArrows = append(gArrows, arrows...)
+			if !gVisual {
+				fmt.Println("Arrows are shown in", gConsole.Bold(gConsole.Yellow("visual")), "mode.")
+			}
+			drawBoard(gGame)
+
+		case cmd == "/swap":
+			if !confirm(l, "Hand your side over to the engine?") {
+				continue
+			}
+
+			// The engine takes over the side to move, the human plays the other.
+			gHumanIsBlack = !gHumanIsBlack
+			fmt.Println("You are playing", gConsole.Bold(gConsole.Yellow(humanColor().Name())), "now.")
+			if err := engineMove(eng, gGame); err != nil {
+				continue
+			}
+			gameStarted = true
+			if isGameOver(gGame) {
+				saveGame(l, gGame, gGameFilename)
+				goto end
+			}
+
+		case strings.HasPrefix(cmd, "/bookmark ") && gSandbox != nil:
+			fmt.Println("Bookmarks are positions of the game,", gConsole.Bold(gConsole.Yellow("/return")), "first.")
+
+		case strings.HasPrefix(cmd, "/bookmark "):
+			name := strings.TrimSpace(strings.TrimPrefix(cmd, "/bookmark "))
+			if gBookmarks == nil {
+				gBookmarks = map[string]int{}
+			}
+			gBookmarks[name] = len(gGame.Moves())
+			fmt.Println("Bookmarked as", gConsole.Bold(gConsole.Yellow(name)).String()+", saved with the game.")
+
+		case cmd == "/bookmarks":
+			game := gGame
+			if gSandbox != nil {
+				game = gSandbox
+			}
+			printBookmarks(game, gBookmarks)
+
+		case strings.HasPrefix(cmd, "/goto-bookmark "):
+			name := strings.TrimSpace(strings.TrimPrefix(cmd, "/goto-bookmark "))
+			game := gGame
+			if gSandbox != nil {
+				game = gSandbox
+			}
+			ply, ok := gBookmarks[name]
+			if !ok || ply > len(game.Moves()) {
+				fmt.Println("No bookmark", gConsole.Bold(gConsole.Yellow(name)).String()+".")
+				continue
+			}
+			// Look around on a copy, the game stays where it is.
+			gSandbox, gGame = game, rewindGame(game, ply)
+			if gAnalysis != nil {
+				gAnalysis.ahead = nil
+			}
+			fmt.Println("At", gConsole.Bold(gConsole.Yellow(name)).String()+", play on in the sandbox,",
+				gConsole.Bold(gConsole.Yellow("/return")), "to get back to the game.")
+			drawBoard(gGame)
+
+		case cmd == "/bindings":
+			printBindings()
+
 		case strings.HasPrefix(cmd, "/keys"):
 			cmd := strings.SplitN(cmd, " ", 2)
 			if len(cmd) > 1 {
@@ -254,37 +753,84 @@ func shell() {
 			}
 
 		case cmd == "/quit":
+			if gSandbox != nil { // Save the real game.
+				gGame, gSandbox, gAnalysis = gSandbox, nil, nil
+			}
 
 			// Save the game.
-			if gameStarted && savePGN(gGame, gGameFilename) == nil { // Success
-				fmt.Println("Game saved to", gConsole.Bold(gConsole.Red(gGameFilename)))
+			if gameStarted {
+				if err == io.EOF { // No input left to answer questions.
+					saveGame(nil, gGame, gGameFilename)
+				} else {
+					saveGame(l, gGame, gGameFilename)
+				}
 			}
 
 			goto end
 
 		default:
-			// Send the human move to engine and get a counter move
-			engineMoveNext(eng, gGame, cmd)
-			gameStarted = true
-			if isGameOver(gGame) {
-				// Save the game.
-				if savePGN(gGame, gGameFilename) == nil { // Success
-					fmt.Println("Game saved to", gConsole.Bold(gConsole.Red(gGameFilename)))
-
-					// If analysis is request, upload the game to lichess.org and open it in a browser.
-					if gLichessAuthTok != "" {
-						lic := NewLichessClient(gLichessAuthTok, "Piñata "+gVersion)
-						_, url, err := lic.Import(gGameFilename)
-						if err != nil {
-							fmt.Println("Unable to export the game to https://lichess.org,", err)
-							goto end
-						}
-						openbrowser(url)
+			if gSandbox != nil { // No engine in the sandbox.
+				move, err := decodeMove(gGame, cmd)
+				if err != nil {
+					printMoveError(gGame, err)
+					continue
+				}
+				if err = applyMove(gGame, move); err != nil {
+					fmt.Println(err)
+					continue
+				}
+				if gAnalysis != nil {
+					gAnalysis.played(move)
+				}
+				drawBoard(gGame)
+				isGameOver(gGame)
+				continue
+			}
+
+			if gTwoPlayer {
+				humanMove(gGame, cmd)
+			} else {
+				if gRepertoire != nil && !gRepertoire.allow(gGame, cmd) {
+					continue
+				}
+				if gPremove { // The engine replies in the background.
+					if playHumanMove(gGame, cmd) != nil {
+						continue
+					}
+					gameStarted = true
+					if gGame.Outcome() == chess.NoOutcome {
+						reply = engineReplyAsync(l, eng, gGame)
+					} else if gameOver() {
+						goto end
 					}
+					continue
 				}
+				// Send the human move to engine and get a counter move
+				engineMoveNext(eng, gGame, cmd)
+				if gRepertoire != nil {
+					gRepertoire.drill(gGame)
+				}
+			}
+			gameStarted = true
+			if gameOver() {
 				goto end
 			}
 		}
 	}
 end:
+	if gGame.Outcome() != chess.NoOutcome && !exploring {
+		printEvalGraph(gEvals)
+
+		// Drawn by repetition or the move rules, resigned or adjudicated, there are moves left to explore.
+		if len(gGame.ValidMoves()) > 0 && confirm(l, "Explore on from the final position?") {
+			// A fresh game from the position, the saved game and its result stay as they are.
+			fen, _ := chess.FEN(gGame.FEN())
+			gSandbox, gGame = gGame, chess.NewGame(fen, chess.UseNotation(chess.AlgebraicNotation{}))
+			exploring, gameStarted = true, false
+			fmt.Println("Play moves for both sides,", gConsole.Bold(gConsole.Yellow("/return")), "or",
+				gConsole.Bold(gConsole.Yellow("/quit")), "when done.")
+			drawBoard(gGame)
+			goto play
+		}
+	}
 }
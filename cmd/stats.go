@@ -0,0 +1,141 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+// Training scores kept across sessions in the `--stats` file.
+type stats struct {
+	Blindfold []blindfoldScore `json:"blindfold"`
+	Accuracy  []accuracyScore  `json:"accuracy"`
+}
+
+// Outcome of a blindfold test.
+type blindfoldScore struct {
+	Date    string `json:"date"`
+	Moves   int    `json:"moves"`   // Moves played blind.
+	Correct int    `json:"correct"` // Squares recalled right.
+	Squares int    `json:"squares"` // Squares with a piece, on the board or recalled.
+}
+
+// Accuracy of the human's moves in a game against the engine.
+type accuracyScore struct {
+	Date     string  `json:"date"`
+	Color    string  `json:"color"`    // The human's side.
+	Accuracy float64 `json:"accuracy"` // Percent, see gameAccuracy.
+}
+
+func (s blindfoldScore) percent() float64 {
+	if s.Squares == 0 {
+		return 100
+	}
+	return 100 * float64(s.Correct) / float64(s.Squares)
+}
+
+// Load the stats file, empty stats if there is none yet.
+func loadStats(filename string) (*stats, error) {
+	s := &stats{}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s is not a stats file", filename)
+	}
+	return s, nil
+}
+
+// Record the blindfold test and print how it compares with the earlier ones.
+func recordBlindfold(score blindfoldScore) {
+	s, err := loadStats(gStatsFile)
+	if err != nil {
+		fmt.Println("Unable to read the stats,", err)
+		return
+	}
+	score.Date = time.Now().Format("2006-01-02")
+	s.Blindfold = append(s.Blindfold, score)
+	if !saveStats(s) {
+		return
+	}
+
+	best, sum := 0.0, 0.0
+	for _, b := range s.Blindfold {
+		if b.percent() > best {
+			best = b.percent()
+		}
+		sum += b.percent()
+	}
+	fmt.Printf("Blindfold tests so far: %d, best %.0f%%, average %.0f%%.\n", len(s.Blindfold), best, sum/float64(len(s.Blindfold)))
+}
+
+// Record the human's accuracy in the game against the engine, if its moves
+// were evaluated, and print how it compares with the earlier games.
+func recordAccuracy(game *chess.Game) {
+	accuracy, ok := gameAccuracy(game, gEvals)[humanColor()]
+	if gTwoPlayer || !ok {
+		return
+	}
+	s, err := loadStats(gStatsFile)
+	if err != nil {
+		fmt.Println("Unable to read the stats,", err)
+		return
+	}
+	s.Accuracy = append(s.Accuracy, accuracyScore{
+		Date:     time.Now().Format("2006-01-02"),
+		Color:    strings.ToLower(humanColor().Name()),
+		Accuracy: math.Round(accuracy*10) / 10,
+	})
+	if !saveStats(s) {
+		return
+	}
+
+	best, sum := 0.0, 0.0
+	for _, a := range s.Accuracy {
+		best = math.Max(best, a.Accuracy)
+		sum += a.Accuracy
+	}
+	fmt.Printf("Games with accuracy so far: %d, best %.0f%%, average %.0f%%.\n", len(s.Accuracy), best, sum/float64(len(s.Accuracy)))
+}
+
+// Write the stats file, false if it failed.
+func saveStats(s *stats) bool {
+	if dryRun(gStatsFile) {
+		return false
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(gStatsFile, string(data)+"\n")
+	}
+	if err != nil {
+		fmt.Println("Unable to save the stats to", gConsole.Bold(gConsole.Red(gStatsFile)).String()+",", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/abperiasamy/chess"
+)
+
+const (
+	gSVGSquare = 45 // Square size in pixels.
+	gSVGMargin = 20 // Room for the file and rank labels.
+	gSVGLight  = "#f0d9b5"
+	gSVGDark   = "#b58863"
+)
+
+// Piece glyphs of the SVG board, independent of the console settings.
+var gSVGPieces = map[chess.Color]map[chess.PieceType]string{
+	chess.White: {chess.King: "♔", chess.Queen: "♕", chess.Rook: "♖", chess.Bishop: "♗", chess.Knight: "♘", chess.Pawn: "♙"},
+	chess.Black: {chess.King: "♚", chess.Queen: "♛", chess.Rook: "♜", chess.Bishop: "♝", chess.Knight: "♞", chess.Pawn: "♟"},
+}
+
+// Render the board as an SVG image from White's side.
+func boardSVG(board *chess.Board) string {
+	size := 8*gSVGSquare + gSVGMargin
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", size, size, size, size)
+	fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="white"/>`+"\n", size, size)
+
+	for r := 0; r < 8; r++ {
+		for f := 0; f < 8; f++ {
+			x, y := gSVGMargin+f*gSVGSquare, (7-r)*gSVGSquare
+			fill := gSVGLight
+			if (r+f)%2 == 0 { // a1 is dark.
+				fill = gSVGDark
+			}
+			fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x, y, gSVGSquare, gSVGSquare, fill)
+
+			if p := board.Piece(chess.Square(r*8 + f)); p != chess.NoPiece {
+				fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="%d" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n",
+					x+gSVGSquare/2, y+gSVGSquare/2, gSVGSquare*4/5, gSVGPieces[p.Color()][p.Type()])
+			}
+		}
+	}
+
+	// File and rank labels.
+	for i := 0; i < 8; i++ {
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="12" text-anchor="middle">%c</text>`+"\n",
+			gSVGMargin+i*gSVGSquare+gSVGSquare/2, 8*gSVGSquare+15, 'a'+i)
+		fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="12" text-anchor="middle" dominant-baseline="central">%d</text>`+"\n",
+			gSVGMargin/2, (7-i)*gSVGSquare+gSVGSquare/2, i+1)
+	}
+	svg.WriteString("</svg>\n")
+	return svg.String()
+}
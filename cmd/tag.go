@@ -0,0 +1,95 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// PGN tag names are made of letters, digits and underscores.
+var gTagNameRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+var gTagSets []string
+
+// tagCmd edits the tag pairs of a saved game without replaying it.
+var tagCmd = &cobra.Command{
+	Use:     "tag FILE",
+	Short:   "Edit the tag pairs of a saved game",
+	Example: `  pinata tag pinata.pgn --set Event="Club Night" --set White="Me"`,
+	Args:    cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		onStart(cmd)
+		filename := args[0]
+
+		// Validate all the tag pairs before touching the file.
+		keys := make([]string, 0, len(gTagSets))
+		values := make([]string, 0, len(gTagSets))
+		for _, set := range gTagSets {
+			kv := strings.SplitN(set, "=", 2)
+			if len(kv) != 2 || !gTagNameRegex.MatchString(kv[0]) {
+				fmt.Println(gConsole.Bold(gConsole.Red(set)), "is not a valid tag pair. Use the form Key=\"Value\".")
+				os.Exit(1)
+			}
+			value := kv[1]
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			if strings.ContainsAny(value, "\"\\\n") {
+				fmt.Println("Tag values can not contain quotes, backslashes or line breaks:", gConsole.Bold(gConsole.Red(set)))
+				os.Exit(1)
+			}
+			keys = append(keys, kv[0])
+			values = append(values, value)
+		}
+
+		// Saving one game back would lose the others of a database.
+		if games, err := pgnGames(filename); err == nil && len(games) > 1 {
+			fmt.Println(gConsole.Bold(gConsole.Red(filename)), "holds", len(games), "games, tag edits a single game.")
+			os.Exit(1)
+		}
+		game := readPGN(nil, filename)
+		if game == nil {
+			os.Exit(1)
+		}
+
+		for i := range keys {
+			game.AddTagPair(keys[i], values[i])
+		}
+		if !dryRun(filename) {
+			if err := writePGN(game, filename); err != nil {
+				fmt.Println("Unable to save the game to", gConsole.Bold(gConsole.Red(filename)))
+				os.Exit(1)
+			}
+		}
+
+		for _, tp := range game.TagPairs() {
+			fmt.Println(gConsole.Bold(tp.Key).String() + ": " + tp.Value)
+		}
+	},
+}
+
+func init() {
+	tagCmd.Flags().StringArrayVar(&gTagSets, "set", nil, "set a tag pair, e.g. Event=\"Club Night\"")
+	rootCmd.AddCommand(tagCmd)
+}
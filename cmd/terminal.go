@@ -0,0 +1,138 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/abperiasamy/chess"
+	"github.com/chzyer/readline"
+)
+
+// Redraws on terminal resize, see watchResize. The shell tells when it idles
+// at the prompt, the game is left alone while it or the engine moves.
+type resizer struct {
+	mu   sync.Mutex
+	game *chess.Game // nil while busy.
+}
+
+var gResize resizer
+
+// Let resizes redraw the game while the shell waits for input, nil stops them.
+func (r *resizer) idle(game *chess.Game) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.game = game
+}
+
+// Draw the board again above the prompt.
+func (r *resizer) redraw(l *readline.Instance) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.game == nil {
+		return
+	}
+	if view := boardView(r.game); view != "" {
+		l.Stdout().Write([]byte(view))
+	}
+	l.Refresh()
+}
+
+// Is the standard output attached to a terminal?
+func isTerminal() bool {
+	return readline.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Read single key presses from the standard input in raw mode, without
+// waiting for enter. No keys arrive when the input is not a terminal.
+// Call the returned function to restore the terminal.
+func readKeys() (<-chan byte, func()) {
+	fd := int(os.Stdin.Fd())
+	if !readline.IsTerminal(fd) {
+		return nil, func() {}
+	}
+	state, err := readline.MakeRaw(fd)
+	if err != nil {
+		return nil, func() {}
+	}
+
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if n, err := os.Stdin.Read(buf); err != nil || n == 0 {
+				return
+			}
+			keys <- buf[0]
+		}
+	}()
+	return keys, func() { readline.Restore(fd, state) }
+}
+
+// Read the shell input from the terminal when the standard input is taken.
+func useTTY(cfg *readline.Config) error {
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+	tty, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+
+	fd := int(tty.Fd())
+	var state *readline.State
+	cfg.Stdin = tty
+	cfg.FuncIsTerminal = func() bool {
+		return readline.IsTerminal(fd) && isTerminal()
+	}
+	cfg.FuncMakeRaw = func() (err error) {
+		state, err = readline.MakeRaw(fd)
+		return err
+	}
+	cfg.FuncExitRaw = func() error {
+		if state == nil {
+			return nil
+		}
+		return readline.Restore(fd, state)
+	}
+	return nil
+}
+
+// Guess if the console can render colors. Redirected output never gets colors.
+func colorSupported() bool {
+	if !isTerminal() {
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok { // https://no-color.org
+		return false
+	}
+	if os.Getenv("COLORTERM") != "" {
+		return true
+	}
+
+	term := os.Getenv("TERM")
+	if term == "dumb" {
+		return false
+	}
+	if term == "" && runtime.GOOS != "windows" { // Not a real terminal emulator.
+		return false
+	}
+	return true
+}
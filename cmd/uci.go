@@ -0,0 +1,376 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// UCI protocol client talking to the engine process over stdin/stdout.
+// See http://wbec-ridderkerk.nl/html/UCIProtocol.html
+type uciEngine struct {
+	Name    string               // Reported by "id name".
+	Options map[string]uciOption // Advertised options, keyed by lower case name.
+	OnInfo  func(uciInfo)        // Called with every exact info while searching, if set.
+
+	cmd    *exec.Cmd
+	stdin  *bufio.Writer
+	stdout *bufio.Reader
+}
+
+// Option advertised by the engine during the handshake.
+type uciOption struct {
+	Name    string
+	Type    string // check, spin, combo, button or string
+	Default string
+	Min     int
+	Max     int
+}
+
+// A single "info" line of the search.
+type uciInfo struct {
+	Depth      int
+	SelDepth   int
+	MultiPV    int
+	Time       int // milliseconds
+	Nodes      int
+	NPS        int
+	TBHits     int  // tablebase probes that hit
+	Score      int  // centipawns, or moves to mate if Mate is set
+	Mate       bool // Score is a forced mate
+	Lowerbound bool
+	Upperbound bool
+	PV         []string
+}
+
+// Result of a search.
+type uciResults struct {
+	BestMove string
+	Ponder   string
+	Lines    []uciInfo // Last exact info of each principal variation, best first.
+}
+
+// Start the engine process and complete the UCI handshake.
+func newUCIEngine(path string) (*uciEngine, error) {
+	switch {
+	case isRandomEngine(path):
+		return newBuiltinEngine(gRandomEngine, randomMove(newRand()))
+	case isBuiltinEngine(path):
+		return newBuiltinEngine(gBuiltinEngine, minimaxMove)
+	}
+
+	eng := &uciEngine{Options: make(map[string]uciOption)}
+	eng.cmd = exec.Command(path)
+	stdin, err := eng.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := eng.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = eng.cmd.Start(); err != nil {
+		return nil, err
+	}
+	if gEngineLog != nil {
+		gEngineLog.record(eng.cmd.Process.Pid, "#", "started "+path)
+	}
+	eng.stdin = bufio.NewWriter(stdin)
+	eng.stdout = bufio.NewReader(stdout)
+
+	if err = eng.handshake(); err != nil {
+		eng.Close()
+		return nil, err
+	}
+	return eng, nil
+}
+
+// Learn the engine's name and options. Some older engines check their copy
+// protection and registration around "uciok", "copyprotection checking" then
+// "copyprotection ok" or "error". The handshake waits those out behind an
+// "isready" before anything else is sent, and registers an unregistered
+// engine later.
+func (eng *uciEngine) handshake() error {
+	if err := eng.send("uci"); err != nil {
+		return err
+	}
+	checking := map[string]bool{} // Checks in progress, "copyprotection" or "registration".
+	ready := false
+	for {
+		line, err := eng.readLine()
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(line)
+		switch {
+		case line == "uciok":
+			if err := eng.send("isready"); err != nil {
+				return err
+			}
+		case line == "readyok":
+			ready = true
+		case len(fields) == 2 && (fields[0] == "copyprotection" || fields[0] == "registration"):
+			checking[fields[0]] = fields[1] == "checking"
+			switch line {
+			case "copyprotection error":
+				return errors.New("the engine failed its copy protection check")
+			case "registration error":
+				if err := eng.send("register later"); err != nil {
+					return err
+				}
+			}
+		case strings.HasPrefix(line, "id name "):
+			eng.Name = strings.TrimPrefix(line, "id name ")
+		case strings.HasPrefix(line, "option "):
+			if opt, ok := parseUCIOption(line); ok {
+				eng.Options[strings.ToLower(opt.Name)] = opt
+			}
+		}
+		if ready && !checking["copyprotection"] && !checking["registration"] {
+			return nil
+		}
+	}
+}
+
+// Write a command to the engine.
+func (eng *uciEngine) send(command string) error {
+	if gEngineLog != nil {
+		gEngineLog.record(eng.pid(), ">", command)
+	}
+	if _, err := eng.stdin.WriteString(command + "\n"); err != nil {
+		return err
+	}
+	return eng.stdin.Flush()
+}
+
+// Read the next line from the engine.
+func (eng *uciEngine) readLine() (string, error) {
+	line, err := eng.stdout.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	if gEngineLog != nil {
+		gEngineLog.record(eng.pid(), "<", line)
+	}
+	return line, nil
+}
+
+// Block until the engine is ready to accept new commands.
+func (eng *uciEngine) IsReady() error {
+	if err := eng.send("isready"); err != nil {
+		return err
+	}
+	for {
+		line, err := eng.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "readyok" {
+			return nil
+		}
+	}
+}
+
+// Does the engine advertise this option?
+func (eng *uciEngine) HasOption(name string) bool {
+	_, ok := eng.Options[strings.ToLower(name)]
+	return ok
+}
+
+// Set an engine option.
+func (eng *uciEngine) SendOption(name string, value interface{}) error {
+	return eng.send(fmt.Sprintf("setoption name %s value %v", name, value))
+}
+
+// Set up the position to search.
+func (eng *uciEngine) SetFEN(fen string) error {
+	return eng.send("position fen " + fen)
+}
+
+// Start a search with the given "go" parameters, e.g. "depth 10", and wait for the best move.
+func (eng *uciEngine) Go(params string) (*uciResults, error) {
+	if err := eng.send(strings.TrimSpace("go " + params)); err != nil {
+		return nil, err
+	}
+
+	res := new(uciResults)
+	lines := make(map[int]uciInfo) // Latest exact result of every PV.
+	for {
+		line, err := eng.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(line, "bestmove") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 { // Some engines say nothing when there is no move.
+				break
+			}
+			res.BestMove = fields[1]
+			if len(fields) == 4 && fields[2] == "ponder" {
+				res.Ponder = fields[3]
+			}
+			break
+		}
+
+		info, ok := parseUCIInfo(line)
+		if !ok || info.Lowerbound || info.Upperbound {
+			continue
+		}
+		if info.MultiPV == 0 {
+			info.MultiPV = 1
+		}
+		lines[info.MultiPV] = info
+		if eng.OnInfo != nil {
+			eng.OnInfo(info)
+		}
+	}
+
+	for pv := 1; pv <= len(lines); pv++ {
+		if info, ok := lines[pv]; ok {
+			res.Lines = append(res.Lines, info)
+		}
+	}
+	return res, nil
+}
+
+// Best line of the search, if the engine reported any.
+func (res *uciResults) Best() (uciInfo, bool) {
+	if len(res.Lines) == 0 {
+		return uciInfo{}, false
+	}
+	return res.Lines[0], true
+}
+
+// Check whether the engine found no move, it has none when the game is over.
+// Engines answer "(none)", "0000", "none" or just "bestmove".
+func (res *uciResults) NoMove() bool {
+	switch strings.ToLower(res.BestMove) {
+	case "", "(none)", "0000", "none":
+		return true
+	}
+	return false
+}
+
+// Process id of the engine, 0 for the built-in one.
+func (eng *uciEngine) pid() int {
+	if eng.cmd == nil {
+		return 0
+	}
+	return eng.cmd.Process.Pid
+}
+
+// Stop the engine process.
+func (eng *uciEngine) Close() {
+	eng.send("quit")
+	if eng.cmd == nil { // Built-in, quits by itself.
+		return
+	}
+	eng.cmd.Process.Kill()
+	eng.cmd.Wait()
+}
+
+// Parse "option name Hash type spin default 16 min 1 max 33554432".
+func parseUCIOption(line string) (opt uciOption, ok bool) {
+	fields := strings.Fields(line)
+	key := ""
+	for _, f := range fields[1:] {
+		switch f {
+		case "name", "type", "default", "min", "max", "var":
+			key = f
+			continue
+		}
+		switch key {
+		case "name":
+			opt.Name = strings.TrimSpace(opt.Name + " " + f)
+		case "type":
+			opt.Type = f
+		case "default":
+			opt.Default = strings.TrimSpace(opt.Default + " " + f)
+		case "min":
+			opt.Min, _ = strconv.Atoi(f)
+		case "max":
+			opt.Max, _ = strconv.Atoi(f)
+		}
+	}
+	return opt, opt.Name != ""
+}
+
+// Parse "info depth 12 seldepth 17 multipv 1 score cp 35 nodes 12000 nps 900000 time 13 pv e2e4 e7e5".
+func parseUCIInfo(line string) (info uciInfo, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "info" {
+		return info, false
+	}
+
+	// Integer value following the i-th field.
+	next := func(i int) int {
+		if i+1 < len(fields) {
+			n, _ := strconv.Atoi(fields[i+1])
+			return n
+		}
+		return 0
+	}
+
+	for i := 1; i < len(fields); i++ {
+		switch fields[i] {
+		case "string", "currmove", "currmovenumber":
+			return info, false // Not a search result.
+		case "depth":
+			info.Depth = next(i)
+			i++
+		case "seldepth":
+			info.SelDepth = next(i)
+			i++
+		case "multipv":
+			info.MultiPV = next(i)
+			i++
+		case "time":
+			info.Time = next(i)
+			i++
+		case "nodes":
+			info.Nodes = next(i)
+			i++
+		case "nps":
+			info.NPS = next(i)
+			i++
+		case "tbhits":
+			info.TBHits = next(i)
+			i++
+		case "score":
+			if i+2 < len(fields) {
+				info.Mate = fields[i+1] == "mate"
+				info.Score, _ = strconv.Atoi(fields[i+2])
+				i += 2
+			}
+		case "lowerbound":
+			info.Lowerbound = true
+		case "upperbound":
+			info.Upperbound = true
+		case "pv":
+			info.PV = append([]string(nil), fields[i+1:]...)
+			i = len(fields)
+		}
+	}
+	return info, info.Depth > 0
+}
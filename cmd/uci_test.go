@@ -0,0 +1,173 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// An engine following a script, the lines it answers each command with.
+// Hanging up returns the commands it received. Commands are read as they come,
+// like a pipe's buffer would, while the answers wait for the client.
+func scriptedEngine(script map[string][]string) (*uciEngine, func() []string) {
+	engineIn, toEngine := io.Pipe()
+	fromEngine, engineOut := io.Pipe()
+	pending := make(chan string, 100)
+	received := make(chan []string, 1)
+	go func() {
+		var commands []string
+		scanner := bufio.NewScanner(engineIn)
+		for scanner.Scan() {
+			commands = append(commands, scanner.Text())
+			pending <- scanner.Text()
+		}
+		close(pending)
+		received <- commands
+	}()
+	go func() {
+		for command := range pending {
+			for _, line := range script[command] {
+				io.WriteString(engineOut, line+"\n")
+			}
+		}
+	}()
+
+	eng := &uciEngine{Options: make(map[string]uciOption)}
+	eng.stdin = bufio.NewWriter(toEngine)
+	eng.stdout = bufio.NewReader(fromEngine)
+	hangUp := func() []string {
+		toEngine.Close()
+		fromEngine.Close()
+		return <-received
+	}
+	return eng, hangUp
+}
+
+func TestHandshake(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   map[string][]string
+		err      string   // Part of the error, "" for none.
+		commands []string // Sent by the client.
+	}{
+		{
+			name: "copy protection ok",
+			script: map[string][]string{
+				"uci":     {"id name Old", "copyprotection checking", "uciok"},
+				"isready": {"readyok", "copyprotection ok"},
+			},
+			commands: []string{"uci", "isready"},
+		},
+		{
+			name: "registration error",
+			script: map[string][]string{
+				"uci":     {"id name Old", "registration checking", "uciok"},
+				"isready": {"registration error", "readyok"},
+			},
+			commands: []string{"uci", "isready", "register later"},
+		},
+		{
+			name: "copy protection error",
+			script: map[string][]string{
+				"uci":     {"id name Old", "copyprotection checking", "uciok"},
+				"isready": {"copyprotection error", "readyok"},
+			},
+			err:      "copy protection",
+			commands: []string{"uci", "isready"},
+		},
+	}
+	for _, tt := range tests {
+		eng, hangUp := scriptedEngine(tt.script)
+		err := eng.handshake()
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: handshake: %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: handshake error %v, want one about %s", tt.name, err, tt.err)
+		case err == nil && eng.Name != "Old":
+			t.Errorf("%s: engine name %q, want %q", tt.name, eng.Name, "Old")
+		}
+		if commands := hangUp(); !reflect.DeepEqual(commands, tt.commands) {
+			t.Errorf("%s: client sent %q, want %q", tt.name, commands, tt.commands)
+		}
+	}
+}
+
+// The handshake waits out a check still going on after "readyok".
+func TestHandshakeWaitsForChecks(t *testing.T) {
+	eng, hangUp := scriptedEngine(map[string][]string{
+		"uci":        {"copyprotection checking", "uciok"},
+		"isready":    {"readyok", "copyprotection ok"},
+		"ucinewgame": {"next"},
+	})
+	defer hangUp()
+	if err := eng.handshake(); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	eng.send("ucinewgame")
+	if line, err := eng.readLine(); line != "next" || err != nil {
+		t.Errorf("read %q, %v after the handshake, want the next command's answer", line, err)
+	}
+}
+
+func TestNoMove(t *testing.T) {
+	tests := []struct {
+		bestmove string // The engine's line.
+		move     string
+		noMove   bool
+	}{
+		{"bestmove (none)", "(none)", true},
+		{"bestmove 0000", "0000", true},
+		{"bestmove none", "none", true},
+		{"bestmove", "", true},
+		{"bestmove e2e4 ponder e7e5", "e2e4", false},
+	}
+	for _, tt := range tests {
+		eng, hangUp := scriptedEngine(map[string][]string{"go depth 1": {"info depth 1 score mate 0", tt.bestmove}})
+		res, err := eng.Go("depth 1")
+		hangUp()
+		if err != nil {
+			t.Errorf("%q: %v", tt.bestmove, err)
+			continue
+		}
+		if res.BestMove != tt.move || res.NoMove() != tt.noMove {
+			t.Errorf("%q: best move %q, no move %v, want %q, %v", tt.bestmove, res.BestMove, res.NoMove(), tt.move, tt.noMove)
+		}
+	}
+}
+
+// No move is the end of the game, unless there are moves to play.
+func TestSearchMoveNoMove(t *testing.T) {
+	mated := testGame(t, "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+	start := testGame(t, gStandardFEN)
+	for _, bestmove := range []string{"bestmove (none)", "bestmove 0000", "bestmove"} {
+		eng, hangUp := scriptedEngine(map[string][]string{"go depth 1": {bestmove}})
+		move, res, err := searchMove(eng, mated, "depth 1")
+		if move != nil || res == nil || err != nil {
+			t.Errorf("%q when mated: %v, %v, %v, want no move and no error", bestmove, move, res, err)
+		}
+		move, _, err = searchMove(eng, start, "depth 1")
+		if move != nil || err == nil {
+			t.Errorf("%q with legal moves: %v, %v, want an error", bestmove, move, err)
+		}
+		hangUp()
+	}
+}
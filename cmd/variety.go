@@ -0,0 +1,79 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/abperiasamy/chess"
+)
+
+const gVarietyLines = 4 // Candidate moves `--variety` picks from.
+
+// Source of the `--variety` picks, reproducible with `--seed`.
+var gVarietyRand *rand.Rand
+
+// Ask the engine for the candidate moves of `--variety`. The returned func
+// goes back to the best line only.
+func varietyLines(engine *uciEngine) func() {
+	if gVariety == 0 || !engine.HasOption("MultiPV") {
+		return func() {}
+	}
+	engine.SendOption("MultiPV", gVarietyLines)
+	return func() { engine.SendOption("MultiPV", 1) }
+}
+
+// With `--variety` the engine's move is drawn from its top lines, a softmax
+// of their scores: the variety is the temperature in centipawns, so at 100 a
+// move a pawn worse is played about a third as often as the best. A forced
+// mate is always played, a line that gets mated never.
+func varietyMove(game *chess.Game, results *uciResults, move *chess.Move) *chess.Move {
+	if gVariety == 0 || results == nil || len(results.Lines) < 2 || results.Lines[0].Mate {
+		return move
+	}
+	best := results.Lines[0]
+
+	var candidates []*chess.Move
+	var weights []float64
+	total := 0.0
+	for _, line := range results.Lines {
+		if line.Mate || len(line.PV) == 0 {
+			continue
+		}
+		candidate, err := chess.LongAlgebraicNotation{}.Decode(game.Position(), line.PV[0])
+		if err != nil {
+			continue
+		}
+		weight := math.Exp(float64(line.Score-best.Score) / float64(gVariety))
+		candidates = append(candidates, candidate)
+		weights = append(weights, weight)
+		total += weight
+	}
+
+	if gVarietyRand == nil {
+		gVarietyRand = newRand()
+	}
+	pick := gVarietyRand.Float64() * total
+	for i, weight := range weights {
+		if pick < weight {
+			return candidates[i]
+		}
+		pick -= weight
+	}
+	return move
+}
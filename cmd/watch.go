@@ -0,0 +1,172 @@
+/*
+Copyright © 2021 Anand Babu Periasamy https://twitter.com/abperiasamy
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abperiasamy/chess"
+)
+
+const (
+	gMinWatchDelay = 100 * time.Millisecond
+	gMaxWatchDelay = 10 * time.Second
+)
+
+// Keyboard controls of the watch mode: space pauses, + and - change the speed, q quits.
+type watchControl struct {
+	keys   <-chan byte // nil without a terminal.
+	delay  time.Duration
+	paused bool
+}
+
+// Wait out the delay before the next move, handling the keys meanwhile.
+// Returns false to stop watching.
+func (w *watchControl) wait() bool {
+	w.status()
+	defer w.clearStatus()
+
+	timer := time.NewTimer(w.delay)
+	defer timer.Stop()
+	due := false // The delay is over, but the game is paused.
+	for {
+		select {
+		case key := <-w.keys:
+			switch key {
+			case ' ':
+				w.paused = !w.paused
+				if !w.paused && due {
+					return true
+				}
+			case '+': // Faster.
+				if w.delay /= 2; w.delay < gMinWatchDelay {
+					w.delay = gMinWatchDelay
+				}
+			case '-': // Slower.
+				switch w.delay *= 2; {
+				case w.delay < gMinWatchDelay:
+					w.delay = gMinWatchDelay
+				case w.delay > gMaxWatchDelay:
+					w.delay = gMaxWatchDelay
+				}
+			case 'q', 3: // Ctrl-C does not signal in raw mode.
+				return false
+			}
+			w.status()
+
+		case <-timer.C:
+			if !w.paused {
+				return true
+			}
+			due = true
+		}
+	}
+}
+
+// Show the speed and the keys in place on the current line.
+func (w *watchControl) status() {
+	if w.keys == nil {
+		return
+	}
+	state := fmt.Sprintf("%.1fs per move", w.delay.Seconds())
+	if w.paused {
+		state = gConsole.Bold(gConsole.Yellow("paused")).String()
+	}
+	fmt.Print("\r\033[K" + state + gConsole.Faint("  [space] pause  [+/-] speed  [q] quit").String())
+}
+
+func (w *watchControl) clearStatus() {
+	if w.keys != nil {
+		fmt.Print("\r\033[K")
+	}
+}
+
+// Watch the engine play against itself from the starting or `--fen` position.
+// Each side runs its own engine instance.
+func watch() {
+	gGame = newGame()
+	if isGameOver(gGame) { // No more moves to play.
+		return
+	}
+	gSessionLog.moves(gGame)
+	defer gSessionLog.end()
+
+	players := map[chess.Color]*uciEngine{}
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		eng, _ := newEngine(gEngineBinary)
+		defer eng.Close()
+		eng.IsReady()
+		players[color] = eng
+	}
+
+	keys, restore := readKeys()
+	defer restore()
+	control := &watchControl{keys: keys, delay: gWatchDelay}
+
+	syncMoveCount(gGame)
+	for first := true; !isGameOver(gGame); first = false {
+		if !first && !control.wait() {
+			return
+		}
+
+		// The side to move comes from the position, black moves first in some FENs.
+		color := gGame.Position().Turn()
+		done := showThinking(players[color], gGame)
+		move, results, err := searchMove(players[color], gGame, engineGoParams())
+		done()
+		if err != nil {
+			fmt.Println("Engine failure:", err)
+			return
+		}
+		if move == nil { // The engine sees the game over.
+			isGameOver(gGame)
+			break
+		}
+		gLastInfo = whiteInfo(results, color)
+		recordEval(gGame, gLastInfo)
+
+		prompt := whitePrompt()
+		if color == chess.Black {
+			prompt = blackPrompt()
+		}
+		if gNoColor {
+			prompt += ":] "
+		} else {
+			prompt += "🤖 "
+		}
+		fmt.Println(prompt + showMove(gGame, move))
+		fmt.Print(explainSearch(gGame, results))
+		fmt.Print(explainMove(gGame, move))
+		if err = applyMove(gGame, move); err != nil {
+			fmt.Println("Engine failure:", err)
+			return
+		}
+		mirrorFEN(gGame)
+		gSessionLog.moves(gGame)
+		drawBoard(gGame)
+
+		if reason := deadDrawReason(gGame); reason != "" {
+			fmt.Println("Game drawn:", reason+".")
+			gGame.Draw(chess.DrawOffer)
+		}
+		if reason := adjudicate(gGame, gLastInfo); reason != "" {
+			fmt.Println("Game adjudicated:", reason+".")
+		}
+	}
+	printEvalGraph(gEvals)
+}